@@ -0,0 +1,94 @@
+package ci
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/michxymi/cppenv/internal/config"
+	"github.com/michxymi/cppenv/internal/python"
+)
+
+// Scopes for CacheKey
+const (
+	// ScopeGlobal covers ~/.cppenv: the managed Python
+	ScopeGlobal = "global"
+	// ScopeProject covers the project's .cppenv directory as well
+	ScopeProject = "project"
+)
+
+// LockFiles are committed files, relative to the project root, that pin what
+// gets installed beyond cppenv.toml; those that exist are part of project keys
+var LockFiles = []string{"conan.lock"}
+
+// CacheKey returns a key that changes exactly when the cached cppenv
+// directories for scope would change, e.g. "cppenv-project-linux-amd64-1f2e..."
+// cfg and root are only used for ScopeProject; the key does not depend on
+// where the project is checked out
+func CacheKey(scope, version string, cfg *config.Config, root string) (string, error) {
+	var inputs bytes.Buffer
+	fmt.Fprintf(&inputs, "cppenv %s\n", version)
+	fmt.Fprintf(&inputs, "python %s %s\n", python.PythonVersion, python.ReleaseDate)
+	fmt.Fprintf(&inputs, "platform %s/%s\n", runtime.GOOS, runtime.GOARCH)
+
+	switch scope {
+	case ScopeGlobal:
+	case ScopeProject:
+		if err := writeProjectInputs(&inputs, cfg, root); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("unknown scope %q (want %q or %q)", scope, ScopeGlobal, ScopeProject)
+	}
+
+	sum := sha256.Sum256(inputs.Bytes())
+	return fmt.Sprintf("cppenv-%s-%s-%s-%s", scope, runtime.GOOS, runtime.GOARCH, hex.EncodeToString(sum[:16])), nil
+}
+
+// writeProjectInputs writes the tools and lock files of a project
+// The project root is replaced by ${root} in tool settings, which may
+// contain it after interpolation
+func writeProjectInputs(inputs *bytes.Buffer, cfg *config.Config, root string) error {
+	quotedRoot, err := json.Marshal(root)
+	if err != nil {
+		return err
+	}
+	quotedRoot = bytes.Trim(quotedRoot, `"`)
+
+	names := make([]string, 0, len(cfg.Tools))
+	for name := range cfg.Tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		// encoding/json writes map keys sorted, so the encoding is stable
+		tool, err := json.Marshal(cfg.Tools[name])
+		if err != nil {
+			return fmt.Errorf("failed to encode tool %s: %w", name, err)
+		}
+		tool = bytes.ReplaceAll(tool, quotedRoot, []byte("${root}"))
+		fmt.Fprintf(inputs, "tool %s %s\n", name, tool)
+	}
+
+	for _, name := range LockFiles {
+		content, err := os.ReadFile(filepath.Join(root, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		// Checkouts on Windows may have CRLF line endings
+		content = []byte(strings.ReplaceAll(string(content), "\r\n", "\n"))
+		sum := sha256.Sum256(content)
+		fmt.Fprintf(inputs, "file %s %s\n", name, hex.EncodeToString(sum[:]))
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package ci
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/michxymi/cppenv/internal/config"
+)
+
+func TestCacheKey(t *testing.T) {
+	project := func(root string) *config.Config {
+		return &config.Config{Tools: map[string]config.Tool{
+			"cmake": {Version: "3.28.1"},
+			"mytool": {
+				Version:     "1.0",
+				Expose:      map[string]string{"b": "b/bin", "a": "a/bin"},
+				PostInstall: config.PostInstall{Run: []string{"mytool --data " + filepath.Join(root, "data")}},
+			},
+		}}
+	}
+
+	rootA, rootB := t.TempDir(), t.TempDir()
+	keyA, err := CacheKey(ScopeProject, "1.0.0", project(rootA), rootA)
+	if err != nil {
+		t.Fatalf("CacheKey() failed: %v", err)
+	}
+	if !strings.HasPrefix(keyA, "cppenv-project-") {
+		t.Errorf("expected scope in key, got %q", keyA)
+	}
+
+	// Checkout location does not matter
+	keyB, err := CacheKey(ScopeProject, "1.0.0", project(rootB), rootB)
+	if err != nil {
+		t.Fatalf("CacheKey() failed: %v", err)
+	}
+	if keyA != keyB {
+		t.Errorf("expected the same key in different directories, got %q and %q", keyA, keyB)
+	}
+
+	// Lock files change the key, whatever their line endings
+	if err := os.WriteFile(filepath.Join(rootA, "conan.lock"), []byte("{\n}\n"), 0644); err != nil {
+		t.Fatalf("failed to write lock file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(rootB, "conan.lock"), []byte("{\r\n}\r\n"), 0644); err != nil {
+		t.Fatalf("failed to write lock file: %v", err)
+	}
+	lockedA, _ := CacheKey(ScopeProject, "1.0.0", project(rootA), rootA)
+	lockedB, _ := CacheKey(ScopeProject, "1.0.0", project(rootB), rootB)
+	if lockedA == keyA || lockedA != lockedB {
+		t.Errorf("expected lock file to change the key independent of line endings, got %q, %q and %q", keyA, lockedA, lockedB)
+	}
+
+	changed := project(rootA)
+	changed.Tools["cmake"] = config.Tool{Version: "3.29.0"}
+	if key, _ := CacheKey(ScopeProject, "1.0.0", changed, rootA); key == lockedA {
+		t.Error("expected a tool version change to change the key")
+	}
+	if key, _ := CacheKey(ScopeProject, "1.1.0", project(rootA), rootA); key == lockedA {
+		t.Error("expected a cppenv version change to change the key")
+	}
+
+	// Global keys ignore the project
+	global, err := CacheKey(ScopeGlobal, "1.0.0", nil, "")
+	if err != nil {
+		t.Fatalf("CacheKey() failed: %v", err)
+	}
+	if other, _ := CacheKey(ScopeGlobal, "1.0.0", changed, rootA); global != other {
+		t.Errorf("expected global key to ignore the project, got %q and %q", global, other)
+	}
+
+	if _, err := CacheKey("machine", "1.0.0", nil, ""); err == nil {
+		t.Error("expected error for unknown scope")
+	}
+}
@@ -0,0 +1,194 @@
+// Package ci exports the project environment for CI systems and generates
+// CI configuration from the project
+package ci
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/michxymi/cppenv/internal/environment"
+)
+
+// Formats for Env.Write
+const (
+	FormatGitHub = "github"
+	FormatGitLab = "gitlab"
+	FormatDotenv = "dotenv"
+	FormatJSON   = "json"
+)
+
+// Formats lists the formats Env.Write supports
+var Formats = []string{FormatGitHub, FormatGitLab, FormatDotenv, FormatJSON}
+
+// PathVar holds the directories to add to PATH in FormatGitLab
+// A dotenv report replaces the variables of later jobs, so they prepend it
+// to their own PATH instead
+const PathVar = "CPPENV_PATH"
+
+// Env is the change from one environment to another
+type Env struct {
+	// Path are the directories added to the front of PATH
+	Path []string
+	// Vars are the other variables that changed; nil values are unset
+	// PATH is one of them if it changed in another way than Path describes
+	Vars map[string]*string
+
+	// basePath is the PATH that Path is added to
+	basePath string
+}
+
+// NewEnv describes the change from the environment from to to
+func NewEnv(from, to []string) *Env {
+	env := &Env{Vars: environment.DiffEnv(from, to)}
+	for key, value := range env.Vars {
+		if !isPathVar(key) || value == nil {
+			continue
+		}
+		base := lookup(from, key)
+		if prefix, ok := strings.CutSuffix(*value, base); ok && base != "" {
+			prefix = strings.TrimSuffix(prefix, string(os.PathListSeparator))
+			env.Path = strings.Split(prefix, string(os.PathListSeparator))
+			env.basePath = base
+			delete(env.Vars, key)
+		}
+	}
+	return env
+}
+
+// Write writes the environment in one of Formats
+// For FormatGitHub, the PATH entries go to pathOut and the variables to
+// envOut, which are the files named by $GITHUB_PATH and $GITHUB_ENV; the
+// other formats write everything to envOut
+func (e *Env) Write(format string, pathOut, envOut io.Writer) error {
+	switch format {
+	case FormatGitHub:
+		// GitHub puts later lines of $GITHUB_PATH first, so add them in reverse
+		for i := len(e.Path) - 1; i >= 0; i-- {
+			fmt.Fprintln(pathOut, e.Path[i])
+		}
+		for _, name := range sortedNames(e.Vars) {
+			if e.Vars[name] == nil {
+				return fmt.Errorf("%s: $GITHUB_ENV cannot unset variables", name)
+			}
+			writeGitHubVar(envOut, name, *e.Vars[name])
+		}
+		return nil
+
+	case FormatGitLab, FormatDotenv:
+		vars := e.flatten(format)
+		for _, name := range sortedNames(vars) {
+			if vars[name] == nil {
+				return fmt.Errorf("%s: dotenv files cannot unset variables", name)
+			}
+			value := *vars[name]
+			if format == FormatGitLab {
+				// GitLab dotenv reports take values as they are, on one line
+				if strings.ContainsAny(value, "\r\n") {
+					return fmt.Errorf("%s: GitLab dotenv reports cannot hold multi-line values", name)
+				}
+				fmt.Fprintf(envOut, "%s=%s\n", name, value)
+			} else {
+				fmt.Fprintf(envOut, "%s=%s\n", name, dotenvQuote(value))
+			}
+		}
+		return nil
+
+	case FormatJSON:
+		vars := e.Vars
+		if vars == nil {
+			vars = map[string]*string{}
+		}
+		path := e.Path
+		if path == nil {
+			path = []string{}
+		}
+		data, err := json.MarshalIndent(struct {
+			Path []string           `json:"path"`
+			Env  map[string]*string `json:"env"`
+		}{path, vars}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Fprintln(envOut, string(data))
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported format %q, expected one of %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// flatten returns the variables with Path added as a variable: PathVar
+// for FormatGitLab, and the full value of PATH otherwise
+func (e *Env) flatten(format string) map[string]*string {
+	vars := make(map[string]*string, len(e.Vars)+1)
+	for name, value := range e.Vars {
+		vars[name] = value
+	}
+	if len(e.Path) == 0 {
+		return vars
+	}
+	sep := string(os.PathListSeparator)
+	if format == FormatGitLab {
+		path := strings.Join(e.Path, sep)
+		vars[PathVar] = &path
+	} else {
+		path := strings.Join(e.Path, sep) + sep + e.basePath
+		vars["PATH"] = &path
+	}
+	return vars
+}
+
+// writeGitHubVar writes a variable for $GITHUB_ENV, using a heredoc for
+// values with line breaks
+func writeGitHubVar(w io.Writer, name, value string) {
+	if !strings.ContainsAny(value, "\r\n") {
+		fmt.Fprintf(w, "%s=%s\n", name, value)
+		return
+	}
+	delimiter := "CPPENV_EOF"
+	for i := 1; strings.Contains(value, delimiter); i++ {
+		delimiter = fmt.Sprintf("CPPENV_EOF_%d", i)
+	}
+	fmt.Fprintf(w, "%s<<%s\n%s\n%s\n", name, delimiter, value, delimiter)
+}
+
+// dotenvQuote double-quotes values that a dotenv parser would not read back as they are
+func dotenvQuote(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\r\n\"'#\\$") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+func isPathVar(key string) bool {
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(key, "PATH")
+	}
+	return key == "PATH"
+}
+
+// lookup returns the value of a variable in env, ignoring case on Windows
+func lookup(env []string, key string) string {
+	for _, e := range env {
+		k, value, _ := strings.Cut(e, "=")
+		if k == key || (runtime.GOOS == "windows" && strings.EqualFold(k, key)) {
+			return value
+		}
+	}
+	return ""
+}
+
+func sortedNames(vars map[string]*string) []string {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,82 @@
+package ci
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNewEnv(t *testing.T) {
+	sep := string(os.PathListSeparator)
+	from := []string{"PATH=/usr/bin", "HOME=/home/me", "OLD=1"}
+	to := []string{"PATH=/venv/bin" + sep + "/tools" + sep + "/usr/bin", "HOME=/home/me", "FOO=bar"}
+
+	env := NewEnv(from, to)
+	if strings.Join(env.Path, " ") != "/venv/bin /tools" {
+		t.Errorf("expected PATH entries, got %v", env.Path)
+	}
+	if len(env.Vars) != 2 || *env.Vars["FOO"] != "bar" || env.Vars["OLD"] != nil {
+		t.Errorf("expected FOO set and OLD unset, got %v", env.Vars)
+	}
+
+	// PATH changed other than at the front is kept as a variable
+	env = NewEnv(from, []string{"PATH=/usr/bin" + sep + "/extra"})
+	if len(env.Path) != 0 || env.Vars["PATH"] == nil {
+		t.Errorf("expected PATH as a variable, got %+v", env)
+	}
+}
+
+func TestEnvWrite(t *testing.T) {
+	sep := string(os.PathListSeparator)
+	multi := "line1\nline2"
+	quoted := "a b"
+	env := NewEnv(
+		[]string{"PATH=/usr/bin"},
+		[]string{"PATH=/a" + sep + "/b" + sep + "/usr/bin", "FOO=bar", "SPACE=" + quoted},
+	)
+
+	var path, vars strings.Builder
+	if err := env.Write(FormatGitHub, &path, &vars); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	if path.String() != "/b\n/a\n" {
+		t.Errorf("expected PATH entries in reverse for $GITHUB_PATH, got %q", path.String())
+	}
+	if vars.String() != "FOO=bar\nSPACE=a b\n" {
+		t.Errorf("unexpected $GITHUB_ENV content %q", vars.String())
+	}
+
+	expected := map[string]string{
+		FormatGitLab: "CPPENV_PATH=/a" + sep + "/b\nFOO=bar\nSPACE=a b\n",
+		FormatDotenv: "FOO=bar\nPATH=/a" + sep + "/b" + sep + "/usr/bin\nSPACE=\"a b\"\n",
+		FormatJSON:   "{\n  \"path\": [\n    \"/a\",\n    \"/b\"\n  ],\n  \"env\": {\n    \"FOO\": \"bar\",\n    \"SPACE\": \"a b\"\n  }\n}\n",
+	}
+	for format, want := range expected {
+		var out strings.Builder
+		if err := env.Write(format, &out, &out); err != nil {
+			t.Fatalf("Write(%s) failed: %v", format, err)
+		}
+		if out.String() != want {
+			t.Errorf("Write(%s) = %q, expected %q", format, out.String(), want)
+		}
+	}
+
+	env = &Env{Vars: map[string]*string{"MULTI": &multi}}
+	vars.Reset()
+	env.Write(FormatGitHub, &path, &vars)
+	if vars.String() != "MULTI<<CPPENV_EOF\nline1\nline2\nCPPENV_EOF\n" {
+		t.Errorf("expected a heredoc for multi-line values, got %q", vars.String())
+	}
+	if err := env.Write(FormatGitLab, &vars, &vars); err == nil {
+		t.Error("expected error for multi-line values in GitLab format")
+	}
+	env = &Env{Vars: map[string]*string{"OLD": nil}}
+	for _, format := range []string{FormatGitHub, FormatGitLab, FormatDotenv} {
+		if err := env.Write(format, &vars, &vars); err == nil {
+			t.Errorf("expected error for an unset variable in %s format", format)
+		}
+	}
+	if err := env.Write("yaml", &vars, &vars); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
@@ -0,0 +1,216 @@
+package ci
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/michxymi/cppenv/internal/config"
+)
+
+// Providers for Generate
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
+// Providers lists the CI systems Generate writes workflows for
+var Providers = []string{ProviderGitHub, ProviderGitLab}
+
+// Scripts are the scripts generated workflows run, in this order, when the
+// project has them; a project with a "ci" script runs only that instead
+var Scripts = []string{"configure", "build", "test", "check-format"}
+
+// GoVersion is the Go version generated files install cppenv with
+const GoVersion = "1.25"
+
+// gitHubRunners are the GitHub runners for each platform
+var gitHubRunners = []string{"ubuntu-latest", "macos-latest", "windows-latest"}
+
+// gitLabJob is a GitLab job for one platform
+type gitLabJob struct {
+	name  string
+	image string
+	tag   string
+	// hasGo is set if the image comes with Go, which installs cppenv
+	hasGo bool
+}
+
+// gitLabJobs are the GitLab jobs for each platform, on GitLab-hosted runners
+var gitLabJobs = []gitLabJob{
+	{name: "linux", image: "golang:" + GoVersion, tag: "saas-linux-small-amd64", hasGo: true},
+	{name: "macos", image: "macos-14-xcode-15", tag: "saas-macos-medium-m1"},
+	{name: "windows", tag: "saas-windows-medium-amd64"},
+}
+
+// releaseVersion matches cppenv versions that are releases, as opposed to
+// "dev" or Go pseudo-versions
+var releaseVersion = regexp.MustCompile(`^v\d+\.\d+\.\d+$`)
+
+// installedVersion finds the cppenv version a generated workflow installs
+var installedVersion = regexp.MustCompile(`go install github\.com/michxymi/cppenv@(\S+)`)
+
+// WorkflowPath returns the file a provider reads its workflow from,
+// relative to the project root
+func WorkflowPath(provider string) (string, error) {
+	switch provider {
+	case ProviderGitHub:
+		return ".github/workflows/cppenv.yml", nil
+	case ProviderGitLab:
+		return ".gitlab-ci.yml", nil
+	default:
+		return "", fmt.Errorf("unknown provider %q (want one of %s)", provider, strings.Join(Providers, ", "))
+	}
+}
+
+// Generate returns a workflow that installs cppenv version and the project's
+// tools, caches them and runs the project's CI scripts on every platform
+// The same inputs always give the same workflow
+func Generate(provider, version string, cfg *config.Config) ([]byte, error) {
+	scripts := ciScripts(cfg)
+	if len(scripts) == 0 {
+		return nil, fmt.Errorf("no scripts to run; add a %q script or some of %s to cppenv.toml", "ci", strings.Join(Scripts, ", "))
+	}
+	version = installVersion(version)
+	install := InstallCommand(version)
+
+	var b strings.Builder
+	fmt.Fprintln(&b, "# Generated by 'cppenv ci generate' from cppenv.toml; run it again instead of")
+	fmt.Fprintln(&b, "# editing this file. 'cppenv ci generate --check' reports when it is out of date.")
+	fmt.Fprintln(&b)
+	switch provider {
+	case ProviderGitHub:
+		writeGitHubWorkflow(&b, install, scripts)
+	case ProviderGitLab:
+		writeGitLabWorkflow(&b, install, version, scripts)
+	default:
+		_, err := WorkflowPath(provider)
+		return nil, err
+	}
+	return []byte(b.String()), nil
+}
+
+// InstallCommand returns the command that installs cppenv version, or the
+// latest release for development builds
+func InstallCommand(version string) string {
+	return "go install github.com/michxymi/cppenv@" + installVersion(version)
+}
+
+// WorkflowVersion returns the cppenv version a generated workflow installs,
+// e.g. "v1.2.3" or "latest"; ok is false if it installs none
+func WorkflowVersion(workflow []byte) (version string, ok bool) {
+	match := installedVersion.FindSubmatch(workflow)
+	if match == nil {
+		return "", false
+	}
+	return string(match[1]), true
+}
+
+// installVersion returns the version to install for a build of cppenv
+func installVersion(version string) string {
+	if version != "latest" && !releaseVersion.MatchString(version) {
+		return "latest"
+	}
+	return version
+}
+
+// ciScripts returns the scripts a workflow runs for the project
+func ciScripts(cfg *config.Config) []string {
+	if _, ok := cfg.Scripts["ci"]; ok {
+		return []string{"ci"}
+	}
+	var scripts []string
+	for _, name := range Scripts {
+		if _, ok := cfg.Scripts[name]; ok {
+			scripts = append(scripts, name)
+		}
+	}
+	return scripts
+}
+
+func writeGitHubWorkflow(b *strings.Builder, install string, scripts []string) {
+	fmt.Fprintf(b, `name: cppenv
+
+on:
+  push:
+  pull_request:
+
+jobs:
+  ci:
+    strategy:
+      fail-fast: false
+      matrix:
+        os: [%s]
+    runs-on: ${{ matrix.os }}
+
+    steps:
+      - uses: actions/checkout@v4
+
+      - uses: actions/setup-go@v5
+        with:
+          go-version: '%s'
+          cache: false
+
+      - name: Install cppenv
+        run: %s
+
+      - name: Compute cache key
+        id: cppenv
+        shell: bash
+        run: echo "key=$(cppenv cache-key)" >> "$GITHUB_OUTPUT"
+
+      - uses: actions/cache@v4
+        with:
+          path: |
+            ~/.cppenv/python
+            .cppenv
+          key: ${{ steps.cppenv.outputs.key }}
+
+      - name: Install tools
+        run: cppenv install
+`, strings.Join(gitHubRunners, ", "), GoVersion, install)
+
+	for _, name := range scripts {
+		fmt.Fprintf(b, "\n      - name: Run %s\n        run: cppenv run %s\n", name, name)
+	}
+}
+
+// writeGitLabWorkflow writes one job per platform
+// GitLab only caches files inside the project, so CPPENV_HOME moves the
+// global directory there, and the cache is keyed by cppenv.toml since the
+// key cannot come from a command
+func writeGitLabWorkflow(b *strings.Builder, install, version string, scripts []string) {
+	fmt.Fprintf(b, `variables:
+  CPPENV_HOME: $CI_PROJECT_DIR/.cppenv-home
+
+.cppenv:
+  cache:
+    key:
+      files:
+        - cppenv.toml
+      prefix: $CI_JOB_NAME_SLUG-%s
+    paths:
+      - .cppenv-home/python
+      - .cppenv
+  before_script:
+    - %s
+    - cppenv install
+  script:
+`, version, install)
+	for _, name := range scripts {
+		fmt.Fprintf(b, "    - cppenv run %s\n", name)
+	}
+
+	for _, job := range gitLabJobs {
+		fmt.Fprintln(b)
+		if !job.hasGo {
+			fmt.Fprintf(b, "# Installing cppenv needs Go %s or later on the runner's PATH, which\n", GoVersion)
+			fmt.Fprintln(b, "# GitLab's hosted runners for this platform do not have by default")
+		}
+		fmt.Fprintf(b, "cppenv:%s:\n  extends: .cppenv\n", job.name)
+		if job.image != "" {
+			fmt.Fprintf(b, "  image: %s\n", job.image)
+		}
+		fmt.Fprintf(b, "  tags: [%s]\n", job.tag)
+	}
+}
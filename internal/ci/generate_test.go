@@ -0,0 +1,76 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/michxymi/cppenv/internal/config"
+)
+
+func TestGenerate(t *testing.T) {
+	cfg := &config.Config{Scripts: map[string]config.Script{
+		"test":      {Cmd: "ctest --test-dir build"},
+		"configure": {Cmd: "cmake -B build"},
+		"clean":     {Cmd: "rm -rf build"},
+	}}
+
+	jobs := map[string][]string{
+		ProviderGitHub: {"os: [ubuntu-latest, macos-latest, windows-latest]"},
+		ProviderGitLab: {"\ncppenv:linux:\n", "\ncppenv:macos:\n", "\ncppenv:windows:\n"},
+	}
+
+	for _, provider := range Providers {
+		t.Run(provider, func(t *testing.T) {
+			workflow, err := Generate(provider, "v1.2.3", cfg)
+			if err != nil {
+				t.Fatalf("Generate() failed: %v", err)
+			}
+			out := string(workflow)
+
+			if !strings.Contains(out, "go install github.com/michxymi/cppenv@v1.2.3\n") {
+				t.Errorf("expected pinned cppenv install, got:\n%s", out)
+			}
+			configure := strings.Index(out, "cppenv run configure\n")
+			test := strings.Index(out, "cppenv run test\n")
+			if configure < 0 || test < configure || strings.Contains(out, "cppenv run clean") {
+				t.Errorf("expected configure then test and no other scripts, got:\n%s", out)
+			}
+			for _, job := range jobs[provider] {
+				if !strings.Contains(out, job) {
+					t.Errorf("expected %q, got:\n%s", job, out)
+				}
+			}
+
+			again, _ := Generate(provider, "v1.2.3", cfg)
+			if string(again) != out {
+				t.Error("expected the same workflow every time")
+			}
+		})
+	}
+
+	// Development builds install the latest release
+	workflow, err := Generate(ProviderGitHub, "v0.0.0-20240101000000-abcdef123456", cfg)
+	if err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+	if !strings.Contains(string(workflow), "cppenv@latest\n") {
+		t.Errorf("expected latest cppenv for a development build, got:\n%s", workflow)
+	}
+	if version, _ := WorkflowVersion(workflow); version != "latest" {
+		t.Errorf("expected WorkflowVersion() to find latest, got %q", version)
+	}
+
+	// A ci script replaces the individual scripts
+	cfg.Scripts["ci"] = config.Script{Steps: []config.Step{{Script: "configure"}, {Script: "test"}}}
+	workflow, _ = Generate(ProviderGitHub, "v1.2.3", cfg)
+	if !strings.Contains(string(workflow), "cppenv run ci\n") || strings.Contains(string(workflow), "cppenv run test\n") {
+		t.Errorf("expected only the ci script, got:\n%s", workflow)
+	}
+
+	if _, err := Generate(ProviderGitHub, "v1.2.3", &config.Config{}); err == nil {
+		t.Error("expected error for a project without CI scripts")
+	}
+	if _, err := Generate("jenkins", "v1.2.3", cfg); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/michxymi/cppenv/internal/config"
+	"github.com/michxymi/cppenv/internal/shell"
+	"github.com/spf13/cobra"
+)
+
+var allowCmd = &cobra.Command{
+	Use:   "allow",
+	Short: "Let the shell hook activate the project",
+	Long: `Trusts the current contents of the project's cppenv.toml, so that the shell
+hook (see 'cppenv hook') activates its environment on entering the directory.
+The hook skips projects that were never allowed, or whose cppenv.toml changed
+since, as their PATH and [env] apply to every command typed in the shell.
+
+'cppenv install' allows the project as well.`,
+	Args:         cobra.NoArgs,
+	RunE:         runAllow,
+	SilenceUsage: true,
+}
+
+var denyCmd = &cobra.Command{
+	Use:          "deny",
+	Short:        "Stop the shell hook from activating the project",
+	Args:         cobra.NoArgs,
+	RunE:         runDeny,
+	SilenceUsage: true,
+}
+
+func runAllow(cmd *cobra.Command, args []string) error {
+	configPath, err := config.FindConfig()
+	if err != nil {
+		return fmt.Errorf("no cppenv.toml found, run 'cppenv init' first")
+	}
+	if err := shell.Allow(configPath); err != nil {
+		return fmt.Errorf("failed to allow %s: %w", configPath, err)
+	}
+	fmt.Printf("Allowed %s\n", configPath)
+	return nil
+}
+
+func runDeny(cmd *cobra.Command, args []string) error {
+	configPath, err := config.FindConfig()
+	if err != nil {
+		return fmt.Errorf("no cppenv.toml found, run 'cppenv init' first")
+	}
+	if err := shell.Deny(configPath); err != nil {
+		return fmt.Errorf("failed to deny %s: %w", configPath, err)
+	}
+	fmt.Printf("Denied %s\n", configPath)
+	return nil
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/michxymi/cppenv/internal/ci"
+	"github.com/michxymi/cppenv/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var cacheKeyScope string
+
+var cacheKeyCmd = &cobra.Command{
+	Use:   "cache-key",
+	Short: "Print a key for caching the cppenv directories in CI",
+	Long: `Prints a key that changes exactly when the cached environment would change,
+for use with CI caches.
+
+  global   ~/.cppenv: the cppenv version, Python version and build, OS and
+           architecture
+  project  ~/.cppenv and .cppenv: the global inputs, the tools in cppenv.toml
+           and a committed conan.lock
+
+The key is the same on every machine and in every checkout directory.`,
+	Args:         cobra.NoArgs,
+	RunE:         runCacheKey,
+	SilenceUsage: true,
+}
+
+func init() {
+	cacheKeyCmd.Flags().StringVar(&cacheKeyScope, "scope", ci.ScopeProject, "What the key covers: global or project")
+}
+
+func runCacheKey(cmd *cobra.Command, args []string) error {
+	var cfg *config.Config
+	var root string
+	if cacheKeyScope == ci.ScopeProject {
+		configPath, err := config.FindConfig()
+		if err != nil {
+			return fmt.Errorf("no cppenv.toml found, run 'cppenv init' first")
+		}
+		cfg, err = config.Load(configPath)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		root = filepath.Dir(configPath)
+	}
+
+	key, err := ci.CacheKey(cacheKeyScope, version(), cfg, root)
+	if err != nil {
+		return err
+	}
+	fmt.Println(key)
+	return nil
+}
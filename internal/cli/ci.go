@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/michxymi/cppenv/internal/ci"
+	"github.com/michxymi/cppenv/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ciProviderFlag string
+	ciCheckFlag    bool
+	ciVersionFlag  string
+)
+
+var ciCmd = &cobra.Command{
+	Use:   "ci",
+	Short: "Generate CI configuration from the project",
+}
+
+var ciGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Write a CI workflow that builds the project on every platform",
+	Long: `Writes a workflow that installs cppenv and the project's tools, caches them by
+'cppenv cache-key', and runs the project's scripts on Linux, macOS and Windows:
+the "ci" script if there is one, otherwise configure, build, test and
+check-format, where they exist.
+
+  github  .github/workflows/cppenv.yml
+  gitlab  .gitlab-ci.yml
+
+The workflow installs the cppenv version given by --cppenv-version, by default
+this one if it is a release and the latest release otherwise.
+
+With --check, nothing is written; the command fails if the committed workflow
+differs from the one it would generate. Without --cppenv-version, it compares
+against the version the committed workflow installs, so that upgrading cppenv
+does not make it out of date.`,
+	Args:         cobra.NoArgs,
+	RunE:         runCIGenerate,
+	SilenceUsage: true,
+}
+
+func init() {
+	ciGenerateCmd.Flags().StringVar(&ciProviderFlag, "provider", ci.ProviderGitHub, "CI system: "+strings.Join(ci.Providers, " or "))
+	ciGenerateCmd.Flags().BoolVar(&ciCheckFlag, "check", false, "Fail if the workflow is out of date instead of writing it")
+	ciGenerateCmd.Flags().StringVar(&ciVersionFlag, "cppenv-version", "", "cppenv version the workflow installs, e.g. v1.2.3 or latest (default: this version)")
+	ciCmd.AddCommand(ciGenerateCmd)
+}
+
+func runCIGenerate(cmd *cobra.Command, args []string) error {
+	configPath, err := config.FindConfig()
+	if err != nil {
+		return fmt.Errorf("no cppenv.toml found, run 'cppenv init' first")
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	name, err := ci.WorkflowPath(ciProviderFlag)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(filepath.Dir(configPath), filepath.FromSlash(name))
+
+	if ciCheckFlag {
+		current, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%s does not exist, run 'cppenv ci generate --provider %s'", name, ciProviderFlag)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		// Checkouts on Windows may have CRLF line endings
+		current = bytes.ReplaceAll(current, []byte("\r\n"), []byte("\n"))
+		installed := ciVersionFlag
+		if installed == "" {
+			installed, _ = ci.WorkflowVersion(current)
+		}
+		workflow, err := ci.Generate(ciProviderFlag, installed, cfg)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(current, workflow) {
+			return fmt.Errorf("%s is out of date, run 'cppenv ci generate --provider %s'", name, ciProviderFlag)
+		}
+		fmt.Printf("%s is up to date\n", name)
+		return nil
+	}
+
+	installed := ciVersionFlag
+	if installed == "" {
+		installed = version()
+	}
+	workflow, err := ci.Generate(ciProviderFlag, installed, cfg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(name), err)
+	}
+	if err := os.WriteFile(path, workflow, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	fmt.Printf("Wrote %s\n", name)
+	return nil
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/michxymi/cppenv/internal/config"
+	"github.com/michxymi/cppenv/internal/container"
+	"github.com/spf13/cobra"
+)
+
+var containerForceFlag bool
+
+var containerCmd = &cobra.Command{
+	Use:   "container",
+	Short: "Generate container configuration from the project",
+}
+
+var containerGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Write a Dockerfile and devcontainer.json for the project",
+	Long: `Writes .devcontainer/Dockerfile and .devcontainer/devcontainer.json. The image
+installs cppenv, the managed Python and the tools at the versions pinned in
+cppenv.toml, and sets PATH and the [env] variables as 'cppenv run' does, so
+commands and editors in the container find the tools directly.
+
+The project is mounted at /workspace, and its .cppenv directory is a volume
+filled from the image, so the environment installed on the host is not used
+in the container.
+
+The files do not depend on the machine generating them: ${env.NAME} in [env]
+becomes the build argument ${NAME}, set with 'docker build --build-arg', and
+${arch} the architecture the image is built for.
+
+Files that exist and were not generated by cppenv are only replaced with
+--force.`,
+	Args:         cobra.NoArgs,
+	RunE:         runContainerGenerate,
+	SilenceUsage: true,
+}
+
+func init() {
+	containerGenerateCmd.Flags().BoolVar(&containerForceFlag, "force", false, "Replace files that were not generated by cppenv")
+	containerCmd.AddCommand(containerGenerateCmd)
+}
+
+func runContainerGenerate(cmd *cobra.Command, args []string) error {
+	configPath, err := config.FindConfig()
+	if err != nil {
+		return fmt.Errorf("no cppenv.toml found, run 'cppenv init' first")
+	}
+	// Evaluate the config as it is read inside the container
+	cfg, err := config.LoadAt(configPath, container.Target)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	files, err := container.Generate(cfg, version())
+	if err != nil {
+		return err
+	}
+
+	root := filepath.Dir(configPath)
+	for _, file := range files {
+		path := filepath.Join(root, filepath.FromSlash(file.Path))
+		current, err := os.ReadFile(path)
+		if err == nil && !containerForceFlag && !bytes.Contains(current, []byte(container.Header)) {
+			return fmt.Errorf("%s exists and was not generated by cppenv, use --force to replace it", file.Path)
+		}
+	}
+	for _, file := range files {
+		path := filepath.Join(root, filepath.FromSlash(file.Path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(file.Path), err)
+		}
+		if err := os.WriteFile(path, file.Content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.Path, err)
+		}
+		fmt.Printf("Wrote %s\n", file.Path)
+	}
+	return nil
+}
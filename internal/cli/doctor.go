@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/michxymi/cppenv/internal/config"
+	"github.com/michxymi/cppenv/internal/doctor"
+	"github.com/michxymi/cppenv/internal/environment"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose problems with the cppenv setup",
+	Long: `Runs a series of checks against the managed Python and the project environment
+and reports problems with hints on how to fix them.
+
+With --fix, problems that can be repaired automatically are fixed.`,
+	RunE:         runDoctor,
+	SilenceUsage: true,
+}
+
+var doctorFixFlag bool
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorFixFlag, "fix", false, "Repair problems that can be fixed automatically")
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	// Apply the project's [env] to the tools the checks run; a config that
+	// fails to load is reported by the checks themselves
+	if configPath, err := config.FindConfig(); err == nil {
+		if cfg, err := config.Load(configPath); err == nil {
+			environment.SetProjectEnv(cfg)
+		}
+	}
+
+	failures, warnings := 0, 0
+	category := ""
+
+	for _, check := range doctor.Checks() {
+		if check.Category != category {
+			if category != "" {
+				fmt.Println()
+			}
+			category = check.Category
+			fmt.Printf("%s:\n", category)
+		}
+
+		result := check.Run()
+		if doctorFixFlag && result.Status >= doctor.Warn && check.Fix != nil {
+			printResult(check.Name, result)
+			fmt.Printf("    fixing...\n")
+			if err := check.Fix(); err != nil {
+				fmt.Printf("    fix failed: %v\n", err)
+			}
+			result = check.Run()
+		}
+
+		printResult(check.Name, result)
+		switch result.Status {
+		case doctor.Fail:
+			failures++
+		case doctor.Warn:
+			warnings++
+		}
+	}
+
+	fmt.Println()
+	if failures > 0 {
+		return fmt.Errorf("%d check(s) failed, %d warning(s)", failures, warnings)
+	}
+	if warnings > 0 {
+		fmt.Printf("No failures, %d warning(s).\n", warnings)
+		return nil
+	}
+	fmt.Println("No problems found.")
+	return nil
+}
+
+func printResult(name string, result doctor.Result) {
+	var marker string
+	switch result.Status {
+	case doctor.Pass:
+		marker = "✓"
+	case doctor.Skip:
+		marker = "-"
+	case doctor.Warn:
+		marker = "!"
+	case doctor.Fail:
+		marker = "✗"
+	}
+
+	fmt.Printf("  %s %s: %s\n", marker, name, result.Message)
+	if result.Hint != "" && result.Status >= doctor.Warn {
+		fmt.Printf("    → %s\n", result.Hint)
+	}
+}
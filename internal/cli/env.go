@@ -0,0 +1,307 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/michxymi/cppenv/internal/ci"
+	"github.com/michxymi/cppenv/internal/config"
+	"github.com/michxymi/cppenv/internal/environment"
+	"github.com/michxymi/cppenv/internal/shell"
+	"github.com/spf13/cobra"
+)
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Print the project environment as shell code",
+	Long: `Prints statements that set up the project environment in the current shell:
+the tools on PATH and the variables from [env] and env files.
+
+  eval "$(cppenv env --shell bash)"
+  cppenv env --shell fish | source
+  cppenv env --shell powershell | Out-String | Invoke-Expression
+
+With --format, the environment 'cppenv run' uses, following [run] isolation
+and hermetic mode, is written for a CI system instead, so later steps can
+call the tools directly:
+
+  github  appends to the files named by $GITHUB_PATH and $GITHUB_ENV
+  gitlab  KEY=value lines for an artifacts:reports:dotenv file, with the
+          directories to add to PATH in CPPENV_PATH
+  dotenv  KEY=value lines, quoted where needed
+  json    {"path": [directories added to PATH], "env": {variables}}`,
+	Args:         cobra.NoArgs,
+	RunE:         runEnv,
+	SilenceUsage: true,
+}
+
+var hookCmd = &cobra.Command{
+	Use:   "hook <shell>",
+	Short: "Print a hook that activates projects when entering their directory",
+	Long: `Prints code that activates the project environment whenever the shell enters
+a directory containing cppenv.toml, or one below it, and deactivates it again
+on leaving. Add it to your shell's startup file:
+
+  bash:       eval "$(cppenv hook bash)"        in ~/.bashrc
+  zsh:        eval "$(cppenv hook zsh)"         in ~/.zshrc
+  fish:       cppenv hook fish | source         in ~/.config/fish/config.fish
+  powershell: cppenv hook powershell | Out-String | Invoke-Expression   in $PROFILE
+
+Only projects you allowed are activated: run 'cppenv allow', or
+'cppenv install', in the project first, and again after its cppenv.toml changes.
+
+cppenv runs before every prompt, so edits to cppenv.toml or env files and
+'cppenv install' take effect right away; it does nothing if the project and
+its configuration are the same as before.`,
+	Args:         cobra.ExactArgs(1),
+	ValidArgs:    []string{shell.Bash, shell.Zsh, shell.Fish, shell.PowerShell},
+	RunE:         runHook,
+	SilenceUsage: true,
+}
+
+// hookEnvCmd is run by the shell hooks to get the statements that switch
+// to the environment of the current directory
+var hookEnvCmd = &cobra.Command{
+	Use:    "hook-env <shell>",
+	Args:   cobra.ExactArgs(1),
+	RunE:   runHookEnv,
+	Hidden: true,
+}
+
+// Variables the shell hooks keep their state in
+const (
+	// hookRestoreVar holds the values the activated variables had before
+	hookRestoreVar = "_CPPENV_RESTORE"
+	// hookStampVar identifies the project and configuration that is active
+	hookStampVar = "_CPPENV_STAMP"
+)
+
+var (
+	envShellFlag  string
+	envFormatFlag string
+)
+
+func init() {
+	envCmd.Flags().StringVar(&envShellFlag, "shell", "", "Shell to print statements for: bash, zsh, fish or powershell (default: $SHELL)")
+	envCmd.Flags().StringVar(&envFormatFlag, "format", "", "Write for a CI system instead: "+strings.Join(ci.Formats, ", "))
+	envCmd.MarkFlagsMutuallyExclusive("shell", "format")
+}
+
+func runEnv(cmd *cobra.Command, args []string) error {
+	kind := shell.Kind(shell.Detect())
+	if envShellFlag != "" {
+		kind = shell.Kind(envShellFlag)
+	}
+
+	configPath, err := config.FindConfig()
+	if err != nil {
+		return fmt.Errorf("no cppenv.toml found, run 'cppenv init' first")
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := environment.SetProjectEnv(cfg); err != nil {
+		return err
+	}
+	if !environment.Exists() {
+		return fmt.Errorf("environment not found, run 'cppenv install' first")
+	}
+
+	if envFormatFlag != "" {
+		env, err := environment.GetRunEnv(cfg)
+		if err != nil {
+			return err
+		}
+		ciEnv := ci.NewEnv(os.Environ(), env)
+		if environment.IsHermetic() && envFormatFlag != ci.FormatJSON && slices.Contains(ci.Formats, envFormatFlag) {
+			var cleared []string
+			for name, value := range ciEnv.Vars {
+				if value == nil {
+					cleared = append(cleared, name)
+				}
+			}
+			if len(cleared) > 0 {
+				sort.Strings(cleared)
+				return fmt.Errorf("hermetic mode clears variables, which --format %s cannot do: %s", envFormatFlag, strings.Join(cleared, ", "))
+			}
+		}
+		return writeCIEnv(ciEnv)
+	}
+
+	vars := environment.DiffEnv(os.Environ(), environment.GetActivatedEnv())
+	root := filepath.Dir(configPath)
+	vars[shell.ActivatedVar] = &root
+
+	code, err := shell.Export(kind, vars)
+	if err != nil {
+		return err
+	}
+	fmt.Print(code)
+	return nil
+}
+
+// writeCIEnv writes the environment in the format given by --format
+// In GitHub Actions the github format is appended to $GITHUB_PATH and
+// $GITHUB_ENV; elsewhere it is printed
+func writeCIEnv(env *ci.Env) error {
+	pathFile, envFile := os.Getenv("GITHUB_PATH"), os.Getenv("GITHUB_ENV")
+	if envFormatFlag != ci.FormatGitHub || pathFile == "" || envFile == "" {
+		if envFormatFlag == ci.FormatGitHub {
+			var path, vars strings.Builder
+			if err := env.Write(envFormatFlag, &path, &vars); err != nil {
+				return err
+			}
+			fmt.Printf("# $GITHUB_PATH\n%s# $GITHUB_ENV\n%s", path.String(), vars.String())
+			return nil
+		}
+		return env.Write(envFormatFlag, os.Stdout, os.Stdout)
+	}
+
+	pathOut, err := os.OpenFile(pathFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open $GITHUB_PATH: %w", err)
+	}
+	defer pathOut.Close()
+	envOut, err := os.OpenFile(envFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open $GITHUB_ENV: %w", err)
+	}
+	defer envOut.Close()
+
+	if err := env.Write(envFormatFlag, pathOut, envOut); err != nil {
+		return err
+	}
+	fmt.Printf("Added %d PATH entries and %d variables for later steps\n", len(env.Path), len(env.Vars))
+	return nil
+}
+
+func runHook(cmd *cobra.Command, args []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the cppenv executable: %w", err)
+	}
+	code, err := shell.Hook(shell.Kind(args[0]), exe)
+	if err != nil {
+		return err
+	}
+	fmt.Print(code)
+	return nil
+}
+
+// runHookEnv prints the statements that undo the previous activation and
+// activate the project of the current directory, if they differ
+// Problems are reported on stderr, since the output is evaluated by the shell
+func runHookEnv(cmd *cobra.Command, args []string) error {
+	kind := shell.Kind(args[0])
+
+	var cfg *config.Config
+	var configPath, root, stamp string
+	var blocked error
+	cwd, _ := os.Getwd()
+	if found, err := config.FindProject(cwd); err == nil {
+		configPath, root = found, filepath.Dir(found)
+		os.Chdir(root)
+		if allowed, err := shell.IsAllowed(configPath); !allowed {
+			blocked = fmt.Errorf("%s is not allowed, run 'cppenv allow' to activate it", configPath)
+			if err != nil {
+				blocked = err
+			}
+			stamp = "blocked-" + projectStamp(configPath, nil)
+		} else if cfg, err = config.Load(configPath); err != nil {
+			fmt.Fprintf(os.Stderr, "cppenv: failed to load %s: %v\n", configPath, err)
+		} else {
+			stamp = projectStamp(configPath, cfg.EnvFiles)
+		}
+	}
+	if stamp == os.Getenv(hookStampVar) {
+		return nil
+	}
+
+	// Undo the previous activation, here as well as in the shell
+	vars := decodeRestore(os.Getenv(hookRestoreVar))
+	for key, value := range vars {
+		if value == nil {
+			os.Unsetenv(key)
+		} else {
+			os.Setenv(key, *value)
+		}
+	}
+	vars[hookRestoreVar] = nil
+	vars[hookStampVar] = nil
+
+	switch {
+	case blocked != nil:
+		// Reported once, until the project or its allowance changes
+		fmt.Fprintf(os.Stderr, "cppenv: %v\n", blocked)
+		vars[hookStampVar] = &stamp
+	case stamp != "":
+		if err := environment.SetProjectEnv(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "cppenv: %v\n", err)
+		}
+		if !environment.Exists() {
+			fmt.Fprintf(os.Stderr, "cppenv: environment for %s not found, run 'cppenv install'\n", cfg.Project.Name)
+		}
+
+		activated := environment.DiffEnv(os.Environ(), environment.GetActivatedEnv())
+		activated[shell.ActivatedVar] = &root
+		restore := make(map[string]*string, len(activated))
+		for key, value := range activated {
+			if old, ok := os.LookupEnv(key); ok {
+				restore[key] = &old
+			} else {
+				restore[key] = nil
+			}
+			vars[key] = value
+		}
+		encoded := encodeRestore(restore)
+		vars[hookRestoreVar] = &encoded
+		vars[hookStampVar] = &stamp
+	}
+
+	code, err := shell.Export(kind, vars)
+	if err != nil {
+		return err
+	}
+	fmt.Print(code)
+	return nil
+}
+
+// projectStamp identifies a project and the state of the files its
+// environment depends on
+func projectStamp(configPath string, envFiles []string) string {
+	h := sha256.New()
+	fmt.Fprintln(h, configPath)
+	for _, path := range append([]string{configPath, environment.GetVenvPath(), environment.GetBinPath()}, envFiles...) {
+		if info, err := os.Stat(path); err == nil {
+			fmt.Fprintln(h, path, info.ModTime().UnixNano(), info.Size())
+		} else {
+			fmt.Fprintln(h, path, "missing")
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// encodeRestore encodes variable values so they can be kept in a variable
+func encodeRestore(vars map[string]*string) string {
+	data, _ := json.Marshal(vars)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeRestore decodes the values saved by encodeRestore; it returns an
+// empty map if value is empty or invalid
+func decodeRestore(value string) map[string]*string {
+	vars := make(map[string]*string)
+	if data, err := base64.RawURLEncoding.DecodeString(value); err == nil {
+		json.Unmarshal(data, &vars)
+	}
+	return vars
+}
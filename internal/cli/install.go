@@ -2,10 +2,14 @@ package cli
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/michxymi/cppenv/internal/config"
 	"github.com/michxymi/cppenv/internal/environment"
 	"github.com/michxymi/cppenv/internal/python"
+	"github.com/michxymi/cppenv/internal/shell"
+	"github.com/michxymi/cppenv/internal/shims"
 	"github.com/spf13/cobra"
 )
 
@@ -27,6 +31,9 @@ func runInstall(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
+	if err := environment.SetProjectEnv(cfg); err != nil {
+		return err
+	}
 
 	// Ensure Python is available
 	fmt.Println("Checking Python...")
@@ -46,6 +53,15 @@ func runInstall(cmd *cobra.Command, args []string) error {
 		fmt.Println("Environment already exists")
 	}
 
+	// Uninstall tools that were removed from the config
+	removed, err := environment.UninstallRemovedTools(cfg.Tools)
+	if err != nil {
+		return err
+	}
+	for _, name := range removed {
+		fmt.Printf("Uninstalled %s\n", name)
+	}
+
 	// Install tools
 	fmt.Println("\nInstalling tools...")
 	reqs := cfg.GetRequirements()
@@ -56,6 +72,26 @@ func runInstall(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Link the binaries 'cppenv run' puts on PATH
+	if err := environment.SyncExposedDir(cfg); err != nil {
+		return fmt.Errorf("failed to expose tools: %w", err)
+	}
+
+	// Check that each tool actually runs at the pinned version
+	fmt.Println("\nVerifying tools...")
+	var failed []string
+	for _, result := range environment.VerifyTools(cfg.Tools) {
+		if result.Err != nil {
+			fmt.Printf("  ✗ %s: %v\n", result.Tool, result.Err)
+			failed = append(failed, result.Tool)
+			continue
+		}
+		fmt.Printf("  ✓ %s %s\n", result.Tool, result.Version)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("tool verification failed: %s", strings.Join(failed, ", "))
+	}
+
 	// Update .gitignore
 	if added, err := environment.AddToGitignore(); err != nil {
 		fmt.Printf("Warning: could not update .gitignore: %v\n", err)
@@ -77,6 +113,26 @@ func runInstall(cmd *cobra.Command, args []string) error {
 		fmt.Println("Created CMakeUserPresets.json in .cppenv/")
 	}
 
+	// Installing runs the project's commands, so the shell hook may activate it
+	if err := shell.Allow(configPath); err != nil {
+		fmt.Printf("Warning: could not allow project for the shell hook: %v\n", err)
+	}
+
+	// Record the project for shims, and update them if they are in use
+	root := filepath.Dir(configPath)
+	if err := shims.RegisterProject(root); err != nil {
+		fmt.Printf("Warning: could not register project for shims: %v\n", err)
+	} else if shims.Installed() {
+		if names, skipped, err := shims.Install(); err != nil {
+			fmt.Printf("Warning: could not update shims: %v\n", err)
+		} else {
+			for _, err := range skipped {
+				fmt.Printf("Warning: skipped project for shims: %v\n", err)
+			}
+			fmt.Printf("Updated %d shim(s) in %s\n", len(names), shims.Dir())
+		}
+	}
+
 	fmt.Println("\nDone! You can now use 'cppenv run <command>' to run tools.")
 	return nil
 }
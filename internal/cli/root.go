@@ -1,9 +1,15 @@
 package cli
 
 import (
+	"runtime/debug"
+
 	"github.com/spf13/cobra"
 )
 
+// Version is the cppenv version, set at build time with
+// -ldflags "-X github.com/michxymi/cppenv/internal/cli.Version=v1.2.3"
+var Version = ""
+
 var rootCmd = &cobra.Command{
 	Use:   "cppenv",
 	Short: "Reproducible C++ build environments",
@@ -13,13 +19,36 @@ It manages Python, CMake, Ninja, Conan, Zig (for C/C++ compilation), and other
 build tools in isolated per-project environments.`,
 }
 
+// version returns Version, or the module version for 'go install' builds
+func version() string {
+	if Version != "" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
 func Execute() error {
+	rootCmd.Version = version()
 	return rootCmd.Execute()
 }
 
 func init() {
+	rootCmd.AddCommand(allowCmd)
+	rootCmd.AddCommand(cacheKeyCmd)
+	rootCmd.AddCommand(ciCmd)
+	rootCmd.AddCommand(containerCmd)
+	rootCmd.AddCommand(denyCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(envCmd)
+	rootCmd.AddCommand(hookCmd)
+	rootCmd.AddCommand(hookEnvCmd)
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(installCmd)
 	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(shellCmd)
+	rootCmd.AddCommand(shimsCmd)
 	rootCmd.AddCommand(statusCmd)
 }
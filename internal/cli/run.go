@@ -1,65 +1,407 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"runtime"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
 
 	"github.com/michxymi/cppenv/internal/config"
 	"github.com/michxymi/cppenv/internal/environment"
+	"github.com/michxymi/cppenv/internal/scripts"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var runCmd = &cobra.Command{
-	Use:   "run <command or script> [args...]",
+	Use:   "run [command or script] [args...]",
 	Short: "Run a script or command using the project environment",
 	Long: `Executes a command with the cppenv tools in PATH.
 
 If the first argument matches a script name defined in cppenv.toml's [scripts]
 section, that script will be executed. Otherwise, the command is run directly.
+Without arguments, or with --list, the available scripts are listed.
+
+Arguments after a script name are passed to the script: they replace an {args}
+or $@ placeholder in the script, or are appended to it. Flags for cppenv itself
+go before the script name; use -- to mark where they end.
 
 Examples:
   cppenv run cmake --version
-  cppenv run build              # runs script named "build" from cppenv.toml`,
-	Args:               cobra.MinimumNArgs(1),
+  cppenv run build              # runs script named "build" from cppenv.toml
+  cppenv run test -R parser     # runs "test" with -R parser appended
+  cppenv run -- test -- --help  # passes --help to the script
+
+With --parallel, every argument is a script name and the scripts run
+concurrently. Their output is prefixed with the script name, or with
+--output=buffer shown as a whole when each script finishes:
+  cppenv run -p lint tidy check-format
+  cppenv run -p -j 2 --output=buffer test-debug test-release
+
+With --watch, a script runs again whenever its inputs change, or any file in
+the project if it declares none (except build directories and paths ignored
+by git). A run still going when files change is stopped first.
+
+Scripts with inputs are skipped when nothing changed since their last
+successful run; use --force to run them anyway.
+
+With --hermetic, or [run] inherit-env = false, commands start from a minimal
+environment instead of inheriting variables such as CC, CFLAGS or CMAKE_*;
+--explain lists the inherited variables that can affect the build.
+
+On Linux, --offline runs commands in a network namespace that only has a
+loopback interface, so any attempt to download something fails. All the
+commands of a run share it, so they can still reach each other locally.`,
 	RunE:               runRun,
+	ValidArgsFunction:  completeRun,
+	SilenceUsage:       true,
 	DisableFlagParsing: true,
 }
 
+var (
+	parallelFlag bool
+	jobsFlag     int
+	outputFlag   string
+	forceFlag    bool
+	watchFlag    bool
+	listFlag     bool
+	hermeticFlag bool
+	explainFlag  bool
+	offlineFlag  bool
+)
+
+func init() {
+	runCmd.Flags().BoolVarP(&listFlag, "list", "l", false, "List the scripts in cppenv.toml")
+	runCmd.Flags().BoolVarP(&parallelFlag, "parallel", "p", false, "Run several scripts concurrently")
+	runCmd.Flags().IntVarP(&jobsFlag, "jobs", "j", 0, "Maximum number of scripts to run at once (default: number of CPUs)")
+	runCmd.Flags().BoolVar(&forceFlag, "force", false, "Run scripts with inputs even if they are up to date")
+	runCmd.Flags().BoolVarP(&watchFlag, "watch", "w", false, "Run a script again whenever files change")
+	runCmd.Flags().StringVar(&outputFlag, "output", scripts.OutputPrefix, "Output of parallel scripts: prefix or buffer")
+	runCmd.Flags().BoolVar(&hermeticFlag, "hermetic", false, "Start commands from a minimal environment plus [run] allow-env")
+	runCmd.Flags().BoolVar(&explainFlag, "explain", false, "List inherited variables that can affect the build")
+	runCmd.Flags().BoolVar(&offlineFlag, "offline", false, "Run without network access (Linux only)")
+}
+
 func runRun(cmd *cobra.Command, args []string) error {
+	flagArgs, args := splitRunArgs(cmd.Flags(), args)
+	if err := cmd.Flags().Parse(flagArgs); err != nil {
+		return err
+	}
+	if help, _ := cmd.Flags().GetBool("help"); help {
+		return cmd.Help()
+	}
+	if listFlag || (len(args) == 0 && !explainFlag) {
+		return listScripts()
+	}
+	if outputFlag != scripts.OutputPrefix && outputFlag != scripts.OutputBuffer {
+		return fmt.Errorf("invalid --output %q, expected %q or %q", outputFlag, scripts.OutputPrefix, scripts.OutputBuffer)
+	}
+
+	// Load config for scripts; commands can also run without one
+	var cfg *config.Config
+	if configPath, err := config.FindConfig(); err == nil {
+		if cfg, err = config.Load(configPath); err != nil {
+			return err
+		}
+		if err := environment.SetProjectEnv(cfg); err != nil {
+			return err
+		}
+	}
+	if hermeticFlag {
+		environment.SetHermetic()
+	}
+	if explainFlag {
+		explainEnv()
+		return nil
+	}
+
 	if !environment.Exists() {
 		return fmt.Errorf("environment not found, run 'cppenv install' first")
 	}
+	if offlineFlag {
+		offline, err := environment.IsOffline()
+		if err != nil {
+			return err
+		}
+		if !offline {
+			if err := environment.CheckOffline(); err != nil {
+				return err
+			}
+			// Run again inside a single network namespace, so that commands
+			// can still reach each other over loopback
+			os.Exit(environment.RunOffline(os.Args[1:]))
+		}
+	}
+
+	env, err := environment.GetRunEnv(cfg)
+	if err != nil {
+		return err
+	}
+
+	if watchFlag {
+		if parallelFlag {
+			return fmt.Errorf("--watch cannot be combined with --parallel")
+		}
+		if cfg == nil || !hasScript(cfg, args[0]) {
+			return fmt.Errorf("--watch needs a script from cppenv.toml, got %q", args[0])
+		}
+		return watchScript(cfg, args[0], dropSeparator(args[1:]), env)
+	}
+
+	if parallelFlag {
+		if cfg == nil {
+			return fmt.Errorf("--parallel runs scripts, but no cppenv.toml was found")
+		}
+		return runParallel(cfg, args, env)
+	}
+
+	// Check if first arg is a script name
+	if cfg != nil && hasScript(cfg, args[0]) {
+		return runScript(cfg, args[0], dropSeparator(args[1:]), env)
+	}
+
+	if cfg != nil && !environment.CommandExists(args[0], env) {
+		if suggestion := scripts.Suggest(cfg.Scripts, args[0]); suggestion != "" {
+			return fmt.Errorf("%q is neither a script nor a command, did you mean %q?", args[0], suggestion)
+		}
+	}
+
+	// Run as direct command
+	exitCode := (&environment.Command{Args: args, Env: env}).Run()
+	exit(exitCode)
+	return nil
+}
+
+// exit exits with a command's exit code, pointing out that network access
+// was disabled if the command failed with --offline
+func exit(code int) {
+	if code != 0 && offlineFlag {
+		fmt.Fprintf(os.Stderr, "cppenv: exit code %d with network access disabled (--offline); "+
+			"errors resolving or connecting to hosts above mean the build tried to download something\n", code)
+	}
+	os.Exit(code)
+}
+
+// splitRunArgs separates cppenv's own flags from the command to run
+// Flags come before the command name and may be ended with "--"; everything
+// from the command name on is returned untouched
+func splitRunArgs(flags *pflag.FlagSet, args []string) ([]string, []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return args[:i], args[i+1:]
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			return args[:i], args[i:]
+		}
+		// Skip the value of a flag given as "--flag value"
+		if !strings.Contains(arg, "=") && flagTakesValue(flags, arg) {
+			i++
+		}
+	}
+	return args, nil
+}
+
+// flagTakesValue reports whether a flag argument expects a separate value
+func flagTakesValue(flags *pflag.FlagSet, arg string) bool {
+	var flag *pflag.Flag
+	if name, ok := strings.CutPrefix(arg, "--"); ok {
+		flag = flags.Lookup(name)
+	} else if len(arg) == 2 {
+		flag = flags.ShorthandLookup(arg[1:])
+	}
+	return flag != nil && flag.NoOptDefVal == ""
+}
+
+// listScripts prints the scripts in cppenv.toml with their descriptions and
+// dependencies
+func listScripts() error {
+	configPath, err := config.FindConfig()
+	if err != nil {
+		return fmt.Errorf("no cppenv.toml found, run 'cppenv init' first")
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if len(cfg.Scripts) == 0 {
+		fmt.Println("No scripts defined in cppenv.toml")
+		return nil
+	}
+
+	names := make([]string, 0, len(cfg.Scripts))
+	width := 0
+	for name := range cfg.Scripts {
+		names = append(names, name)
+		width = max(width, len(name))
+	}
+	sort.Strings(names)
+
+	fmt.Println("Scripts:")
+	for _, name := range names {
+		script := cfg.Scripts[name]
+		summary := script.Description
+		if summary == "" {
+			summary = scriptCommand(script)
+		}
+		fmt.Printf("  %-*s  %s\n", width, name, summary)
+		if len(script.Depends) > 0 {
+			fmt.Printf("  %-*s  depends on: %s\n", width, "", strings.Join(script.Depends, ", "))
+		}
+	}
+	return nil
+}
+
+// explainEnv prints the inherited variables that can affect the build and
+// what happens to them
+func explainEnv() {
+	vars := environment.ExplainEnv()
+	if len(vars) == 0 {
+		fmt.Println("No inherited variables affect the build.")
+		return
+	}
+
+	width := 0
+	for _, v := range vars {
+		width = max(width, len(v.Name))
+	}
+
+	fmt.Println("Inherited variables that can affect the build:")
+	for _, v := range vars {
+		value := v.Value
+		if len(value) > 40 {
+			value = value[:37] + "..."
+		}
+		status := "inherited"
+		switch {
+		case v.Overridden:
+			status = "overridden by [env]"
+		case !v.Kept:
+			status = "dropped"
+		case environment.IsHermetic():
+			status = "allowed"
+		}
+		fmt.Printf("  %-*s  %-40s  %s\n", width, v.Name, value, status)
+	}
+	if !environment.IsHermetic() {
+		fmt.Println()
+		fmt.Println("Use --hermetic or set [run] inherit-env = false to drop them.")
+	}
+}
+
+// scriptCommand returns a script's command, or its steps in order
+func scriptCommand(script config.Script) string {
+	if len(script.Steps) == 0 {
+		return script.Cmd
+	}
+	steps := make([]string, len(script.Steps))
+	for i, step := range script.Steps {
+		steps[i] = step.String()
+	}
+	if script.Parallel {
+		return "in parallel: " + strings.Join(steps, ", ")
+	}
+	return strings.Join(steps, " → ")
+}
+
+// completeRun completes script names and the binaries 'cppenv run' puts on
+// PATH for the first argument, and files after it
+func completeRun(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	flagArgs, rest := splitRunArgs(cmd.Flags(), args)
+	cmd.Flags().Parse(flagArgs)
+	if len(rest) > 0 && !parallelFlag {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
 
-	// Try to load config for scripts
 	var cfg *config.Config
 	if configPath, err := config.FindConfig(); err == nil {
 		cfg, _ = config.Load(configPath)
 	}
 
-	// Check if first arg is a script name
-	if cfg != nil && cfg.Scripts != nil {
-		if script, ok := cfg.Scripts[args[0]]; ok {
-			fmt.Printf("→ %s\n", script)
-			return runScript(script)
+	var completions []string
+	if cfg != nil {
+		for name, script := range cfg.Scripts {
+			summary := script.Description
+			if summary == "" {
+				summary = scriptCommand(script)
+			}
+			completions = append(completions, name+"\t"+summary)
 		}
 	}
+	if environment.Exists() {
+		for _, name := range environment.GetRunBinaries(cfg) {
+			completions = append(completions, name+"\ttool")
+		}
+	}
+	sort.Strings(completions)
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
 
-	// Run as direct command
-	exitCode := environment.RunCommand(args)
-	os.Exit(exitCode)
+func hasScript(cfg *config.Config, name string) bool {
+	_, ok := cfg.Scripts[name]
+	return ok
+}
+
+// dropSeparator removes a "--" between a script name and its arguments
+func dropSeparator(args []string) []string {
+	if len(args) > 0 && args[0] == "--" {
+		return args[1:]
+	}
+	return args
+}
+
+func runScript(cfg *config.Config, name string, args []string, env []string) error {
+	root, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	exitCode, err := newRunner(cfg, env, root).Run(name, args)
+	if err != nil {
+		return err
+	}
+	exit(exitCode)
 	return nil
 }
 
-func runScript(script string) error {
-	var args []string
-	if runtime.GOOS == "windows" {
-		args = []string{"cmd", "/c", script}
-	} else {
-		args = []string{"sh", "-c", script}
+func watchScript(cfg *config.Config, name string, args []string, env []string) error {
+	root, err := os.Getwd()
+	if err != nil {
+		return err
 	}
 
-	exitCode := environment.RunCommand(args)
-	os.Exit(exitCode)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return newRunner(cfg, env, root).Watch(ctx, name, args)
+}
+
+func runParallel(cfg *config.Config, names []string, env []string) error {
+	root, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	exitCode, err := newRunner(cfg, env, root).RunParallel(names)
+	if err != nil {
+		return err
+	}
+	exit(exitCode)
 	return nil
 }
+
+func newRunner(cfg *config.Config, env []string, root string) *scripts.Runner {
+	return &scripts.Runner{
+		Scripts:  cfg.Scripts,
+		Env:      env,
+		Root:     root,
+		Jobs:     jobsFlag,
+		Output:   outputFlag,
+		CacheDir: filepath.Join(environment.GetCachePath(), "scripts"),
+		Force:    forceFlag,
+		Tools:    cfg.Tools,
+	}
+}
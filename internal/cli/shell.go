@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/michxymi/cppenv/internal/config"
+	"github.com/michxymi/cppenv/internal/environment"
+	"github.com/michxymi/cppenv/internal/shell"
+	"github.com/spf13/cobra"
+)
+
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Start a shell with the project environment activated",
+	Long: `Starts your shell ($SHELL) with the project's tools on PATH and its [env]
+applied, like 'cppenv run' does for a single command. The prompt shows the
+project name; type 'exit' to leave the shell.`,
+	Args:         cobra.NoArgs,
+	RunE:         runShell,
+	SilenceUsage: true,
+}
+
+func runShell(cmd *cobra.Command, args []string) error {
+	if active := os.Getenv(shell.ActiveVar); active != "" {
+		return fmt.Errorf("already in a cppenv shell for %s, type 'exit' to leave it first", active)
+	}
+
+	configPath, err := config.FindConfig()
+	if err != nil {
+		return fmt.Errorf("no cppenv.toml found, run 'cppenv init' first")
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := environment.SetProjectEnv(cfg); err != nil {
+		return err
+	}
+	if !environment.Exists() {
+		return fmt.Errorf("environment not found, run 'cppenv install' first")
+	}
+
+	dir, err := os.MkdirTemp("", "cppenv-shell-")
+	if err != nil {
+		return fmt.Errorf("failed to create shell startup directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	shellArgs, vars, err := shell.Interactive(shell.Detect(), cfg.Project.Name, environment.GetBinPath(), dir)
+	if err != nil {
+		return err
+	}
+	vars[shell.ActiveVar] = filepath.Dir(configPath)
+
+	env := environment.MergeEnv(environment.GetActivatedEnv(), vars)
+	exitCode := (&environment.Command{Args: shellArgs, Env: env}).Run()
+	os.RemoveAll(dir)
+	os.Exit(exitCode)
+	return nil
+}
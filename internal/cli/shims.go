@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/michxymi/cppenv/internal/shims"
+	"github.com/spf13/cobra"
+)
+
+var shimsCmd = &cobra.Command{
+	Use:   "shims",
+	Short: "Manage shims that run project tools without 'cppenv run'",
+	Long: `Shims are executables in ~/.cppenv/shims named after the tools of your projects.
+Put that directory at the front of PATH and typing cmake, ninja or clang-format
+in any terminal or IDE runs the version pinned by the nearest cppenv.toml, in
+the same environment as 'cppenv run'. Outside projects, the next program of
+that name on PATH runs instead.`,
+}
+
+var shimsInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Create shims for the tools of every installed project",
+	Long: `Creates a shim for every tool 'cppenv run' puts on PATH in the projects
+installed on this machine, and removes shims no project needs anymore.
+Once shims are installed, 'cppenv install' keeps them up to date.`,
+	Args:         cobra.NoArgs,
+	RunE:         runShimsInstall,
+	SilenceUsage: true,
+}
+
+func init() {
+	shimsCmd.AddCommand(shimsInstallCmd)
+}
+
+func runShimsInstall(cmd *cobra.Command, args []string) error {
+	names, skipped, err := shims.Install()
+	if err != nil {
+		return err
+	}
+	for _, err := range skipped {
+		fmt.Printf("Warning: skipped project: %v\n", err)
+	}
+	dir := shims.Dir()
+	fmt.Printf("Installed %d shim(s) in %s\n", len(names), dir)
+	if len(names) > 0 {
+		fmt.Printf("  %s\n", strings.Join(names, ", "))
+	}
+
+	if !onPath(dir) {
+		fmt.Printf("\nAdd %s to the front of your PATH to use them.\n", dir)
+	}
+	return nil
+}
+
+// onPath reports whether a directory is on PATH
+func onPath(dir string) bool {
+	for _, p := range filepath.SplitList(os.Getenv("PATH")) {
+		if filepath.Clean(p) == dir {
+			return true
+		}
+	}
+	return false
+}
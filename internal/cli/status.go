@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/michxymi/cppenv/internal/config"
 	"github.com/michxymi/cppenv/internal/environment"
@@ -48,6 +49,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	if environment.Exists() {
 		fmt.Printf("  Path: %s\n", environment.GetVenvPath())
 		fmt.Println("  Status: installed")
+		fmt.Printf("  Isolation: %s\n", cfg.Run.GetIsolation())
 	} else {
 		fmt.Println("  Status: not installed")
 		fmt.Println("  Run 'cppenv install' to set up")
@@ -56,8 +58,16 @@ func runStatus(cmd *cobra.Command, args []string) error {
 
 	// Tools
 	fmt.Println("Tools:")
-	for pkg, version := range cfg.Tools {
-		fmt.Printf("  %s: %s\n", pkg, version)
+	for pkg, tool := range cfg.Tools {
+		if pkg == "clang-tools" {
+			llvm := tool.GetLLVMVersion()
+			fmt.Printf("  %s: %s (LLVM %s)\n", pkg, tool.Version, llvm)
+			if installed, ok := environment.GetInstalledTool(pkg); ok && installed.GetLLVMVersion() != llvm {
+				fmt.Printf("    installed with LLVM %s, run 'cppenv install' to update\n", installed.GetLLVMVersion())
+			}
+			continue
+		}
+		fmt.Printf("  %s: %s\n", pkg, tool.Version)
 	}
 
 	// Scripts
@@ -65,7 +75,13 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		fmt.Println()
 		fmt.Println("Scripts:")
 		for name, script := range cfg.Scripts {
-			fmt.Printf("  %s: %s\n", name, script)
+			fmt.Printf("  %s: %s\n", name, scriptCommand(script))
+			if script.Description != "" {
+				fmt.Printf("    %s\n", script.Description)
+			}
+			if len(script.Depends) > 0 {
+				fmt.Printf("    depends on: %s\n", strings.Join(script.Depends, ", "))
+			}
 		}
 	}
 
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 )
@@ -18,15 +21,56 @@ var DefaultTools = []string{
 }
 
 type Config struct {
-	Project ProjectConfig     `toml:"project"`
-	Tools   map[string]string `toml:"tools"`
-	Scripts map[string]string `toml:"scripts"`
+	// EnvFiles are dotenv files, relative to the project root, whose
+	// variables are set for every tool cppenv runs; missing files are skipped
+	EnvFiles []string          `toml:"env-files,omitempty"`
+	Project  ProjectConfig     `toml:"project"`
+	Tools    map[string]Tool   `toml:"tools"`
+	Scripts  map[string]Script `toml:"scripts"`
+	Run      RunConfig         `toml:"run,omitempty"`
+	// Vars are user-defined values for ${vars.NAME} references
+	Vars map[string]string `toml:"vars,omitempty"`
+	Env  EnvConfig         `toml:"env,omitempty"`
 }
 
 type ProjectConfig struct {
 	Name string `toml:"name"`
 }
 
+// Isolation modes for commands started by 'cppenv run'
+const (
+	// IsolationTools puts only the configured tools' binaries on PATH
+	IsolationTools = "tools"
+	// IsolationVenv puts the whole venv bin directory on PATH
+	IsolationVenv = "venv"
+)
+
+// RunConfig controls the environment 'cppenv run' starts commands in
+type RunConfig struct {
+	Isolation string `toml:"isolation,omitempty"`
+	// Expose lists extra venv binaries to put on PATH in tools isolation mode
+	Expose []string `toml:"expose,omitempty"`
+	// InheritEnv set to false starts commands from a minimal environment
+	// instead of cppenv's own; see AllowEnv
+	InheritEnv *bool `toml:"inherit-env,omitempty"`
+	// AllowEnv lists extra variables to keep when InheritEnv is false;
+	// a trailing * matches any suffix, e.g. "CONAN_*"
+	AllowEnv []string `toml:"allow-env,omitempty"`
+}
+
+// GetIsolation returns the isolation mode, defaulting to IsolationTools
+func (r RunConfig) GetIsolation() string {
+	if r.Isolation == "" {
+		return IsolationTools
+	}
+	return r.Isolation
+}
+
+// IsHermetic reports whether commands start from a minimal environment
+func (r RunConfig) IsHermetic() bool {
+	return r.InheritEnv != nil && !*r.InheritEnv
+}
+
 // FindConfig searches for cppenv.toml in the current directory
 func FindConfig() (string, error) {
 	cwd, err := os.Getwd()
@@ -40,27 +84,125 @@ func FindConfig() (string, error) {
 	return path, nil
 }
 
+// FindProject searches for cppenv.toml in dir and its parents
+func FindProject(dir string) (string, error) {
+	for {
+		path := filepath.Join(dir, ConfigFile)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("no %s found", ConfigFile)
+		}
+		dir = parent
+	}
+}
+
 // Load reads and parses a cppenv.toml file
 func Load(path string) (*Config, error) {
+	root, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	return LoadAt(path, Target{Root: root, OS: runtime.GOOS, Arch: runtime.GOARCH})
+}
+
+// Target is where a config is used, which its ${...} references expand for
+type Target struct {
+	// Root is the directory containing cppenv.toml
+	Root string
+	// OS and Arch are runtime.GOOS and runtime.GOARCH values, or references
+	// that the generated files resolve
+	OS, Arch string
+	// EnvRef returns what ${env.NAME} expands to, given the expanded default
+	// if there is one; variables are looked up in cppenv's environment if nil
+	EnvRef func(name, fallback string, hasFallback bool) string
+}
+
+// LoadAt reads and parses a cppenv.toml file as if it were used on target,
+// for generating files that set the project up somewhere else
+func LoadAt(path string, target Target) (*Config, error) {
 	var cfg Config
 	if _, err := toml.DecodeFile(path, &cfg); err != nil {
 		return nil, err
 	}
 	if cfg.Tools == nil {
-		cfg.Tools = make(map[string]string)
+		cfg.Tools = make(map[string]Tool)
 	}
 	if cfg.Scripts == nil {
-		cfg.Scripts = make(map[string]string)
+		cfg.Scripts = make(map[string]Script)
+	}
+	if err := cfg.interpolate(target); err != nil {
+		return nil, err
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
 	}
 	return &cfg, nil
 }
 
+// validate checks settings that the TOML decoder cannot
+func (c *Config) validate() error {
+	switch c.Run.GetIsolation() {
+	case IsolationTools, IsolationVenv:
+	default:
+		return fmt.Errorf("run.isolation must be %q or %q, got %q", IsolationTools, IsolationVenv, c.Run.Isolation)
+	}
+	for _, pattern := range c.Run.AllowEnv {
+		if name, _ := strings.CutSuffix(pattern, "*"); name == "" || strings.ContainsAny(name, "=*") {
+			return fmt.Errorf("run.allow-env: invalid variable name %q", pattern)
+		}
+	}
+	for name := range c.Env.Vars {
+		if !IsEnvName(name) {
+			return fmt.Errorf("env: invalid variable name %q", name)
+		}
+	}
+	for platform, vars := range c.Env.Platforms {
+		for name := range vars {
+			if !IsEnvName(name) {
+				return fmt.Errorf("env.%s: invalid variable name %q", platform, name)
+			}
+		}
+	}
+	for name, tool := range c.Tools {
+		if name != "clang-tools" && (tool.LLVM != "" || len(tool.Binaries) > 0) {
+			return fmt.Errorf("tools.%s: 'llvm' and 'binaries' are only supported for clang-tools", name)
+		}
+	}
+	resolveSteps(c.Scripts)
+	for name, script := range c.Scripts {
+		for key := range script.Env {
+			if !IsEnvName(key) {
+				return fmt.Errorf("scripts.%s.env: invalid variable name %q", name, key)
+			}
+		}
+		for _, dep := range script.Depends {
+			if _, ok := c.Scripts[dep]; !ok {
+				return fmt.Errorf("scripts.%s: unknown dependency %q", name, dep)
+			}
+		}
+		for _, step := range script.Steps {
+			if _, ok := c.Scripts[step.Script]; step.Script != "" && !ok {
+				return fmt.Errorf("scripts.%s: unknown script %q in steps", name, step.Script)
+			}
+		}
+	}
+	return CheckScriptCycles(c.Scripts)
+}
+
 // CreateDefault creates a new Config with default tools
+// tools maps package names to pinned versions
 func CreateDefault(projectName string, tools map[string]string) *Config {
+	cfgTools := make(map[string]Tool, len(tools))
+	for pkg, version := range tools {
+		cfgTools[pkg] = Tool{Version: version}
+	}
 	return &Config{
 		Project: ProjectConfig{Name: projectName},
-		Tools:   tools,
-		Scripts: make(map[string]string),
+		Tools:   cfgTools,
+		Scripts: make(map[string]Script),
 	}
 }
 
@@ -79,8 +221,8 @@ func Write(cfg *Config, path string) error {
 // GetRequirements returns pip install requirements (e.g., ["ziglang==0.11.0", ...])
 func (c *Config) GetRequirements() []string {
 	reqs := make([]string, 0, len(c.Tools))
-	for pkg, version := range c.Tools {
-		reqs = append(reqs, pkg+"=="+version)
+	for pkg, tool := range c.Tools {
+		reqs = append(reqs, pkg+"=="+tool.Version)
 	}
 	return reqs
 }
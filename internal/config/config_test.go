@@ -35,16 +35,16 @@ build = "cmake --build build"
 		t.Errorf("expected project name 'test-project', got '%s'", cfg.Project.Name)
 	}
 
-	if cfg.Tools["cmake"] != "3.28.1" {
-		t.Errorf("expected cmake version '3.28.1', got '%s'", cfg.Tools["cmake"])
+	if cfg.Tools["cmake"].Version != "3.28.1" {
+		t.Errorf("expected cmake version '3.28.1', got '%s'", cfg.Tools["cmake"].Version)
 	}
 
-	if cfg.Tools["ninja"] != "1.11.1.1" {
-		t.Errorf("expected ninja version '1.11.1.1', got '%s'", cfg.Tools["ninja"])
+	if cfg.Tools["ninja"].Version != "1.11.1.1" {
+		t.Errorf("expected ninja version '1.11.1.1', got '%s'", cfg.Tools["ninja"].Version)
 	}
 
-	if cfg.Scripts["build"] != "cmake --build build" {
-		t.Errorf("expected build script 'cmake --build build', got '%s'", cfg.Scripts["build"])
+	if cfg.Scripts["build"].Cmd != "cmake --build build" {
+		t.Errorf("expected build script 'cmake --build build', got '%s'", cfg.Scripts["build"].Cmd)
 	}
 }
 
@@ -55,11 +55,32 @@ func TestLoadConfigNotFound(t *testing.T) {
 	}
 }
 
+func TestFindProject(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "src", "lib")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatalf("failed to create directories: %v", err)
+	}
+	if _, err := FindProject(sub); err == nil {
+		t.Error("expected error without cppenv.toml")
+	}
+
+	configPath := filepath.Join(root, ConfigFile)
+	if err := os.WriteFile(configPath, []byte("[project]\nname = \"x\"\n"), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+	for _, dir := range []string{root, sub} {
+		if path, err := FindProject(dir); err != nil || path != configPath {
+			t.Errorf("FindProject(%s) = %q, %v; expected %q", dir, path, err, configPath)
+		}
+	}
+}
+
 func TestGetRequirements(t *testing.T) {
 	cfg := &Config{
-		Tools: map[string]string{
-			"cmake": "3.28.1",
-			"ninja": "1.11.1.1",
+		Tools: map[string]Tool{
+			"cmake": {Version: "3.28.1"},
+			"ninja": {Version: "1.11.1.1"},
 		},
 	}
 
@@ -94,8 +115,8 @@ func TestCreateDefault(t *testing.T) {
 		t.Errorf("expected project name 'my-project', got '%s'", cfg.Project.Name)
 	}
 
-	if cfg.Tools["cmake"] != "3.28.1" {
-		t.Errorf("expected cmake version '3.28.1', got '%s'", cfg.Tools["cmake"])
+	if cfg.Tools["cmake"].Version != "3.28.1" {
+		t.Errorf("expected cmake version '3.28.1', got '%s'", cfg.Tools["cmake"].Version)
 	}
 
 	if cfg.Scripts == nil {
@@ -109,11 +130,11 @@ func TestWriteAndLoad(t *testing.T) {
 
 	original := &Config{
 		Project: ProjectConfig{Name: "roundtrip-test"},
-		Tools: map[string]string{
-			"cmake": "3.28.1",
+		Tools: map[string]Tool{
+			"cmake": {Version: "3.28.1"},
 		},
-		Scripts: map[string]string{
-			"build": "make",
+		Scripts: map[string]Script{
+			"build": {Cmd: "make"},
 		},
 	}
 
@@ -131,8 +152,62 @@ func TestWriteAndLoad(t *testing.T) {
 			original.Project.Name, loaded.Project.Name)
 	}
 
-	if loaded.Tools["cmake"] != original.Tools["cmake"] {
+	if loaded.Tools["cmake"].Version != original.Tools["cmake"].Version {
 		t.Errorf("cmake version mismatch: expected '%s', got '%s'",
-			original.Tools["cmake"], loaded.Tools["cmake"])
+			original.Tools["cmake"].Version, loaded.Tools["cmake"].Version)
+	}
+}
+
+func TestLoadRunConfig(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "cppenv.toml")
+
+	content := `
+[run]
+isolation = "venv"
+expose = ["python"]
+inherit-env = false
+allow-env = ["CONAN_*", "CCACHE_DIR"]
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.Run.GetIsolation() != IsolationVenv {
+		t.Errorf("expected isolation '%s', got '%s'", IsolationVenv, cfg.Run.GetIsolation())
+	}
+	if len(cfg.Run.Expose) != 1 || cfg.Run.Expose[0] != "python" {
+		t.Errorf("expected expose [python], got %v", cfg.Run.Expose)
+	}
+	if !cfg.Run.IsHermetic() || len(cfg.Run.AllowEnv) != 2 {
+		t.Errorf("expected hermetic mode with allow-env, got %+v", cfg.Run)
+	}
+
+	if err := os.WriteFile(configPath, []byte("[run]\nisolation = \"none\"\n"), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+	if _, err := Load(configPath); err == nil {
+		t.Error("expected error for invalid isolation mode")
+	}
+
+	if err := os.WriteFile(configPath, []byte("[run]\nallow-env = [\"*\"]\n"), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+	if _, err := Load(configPath); err == nil {
+		t.Error("expected error for invalid allow-env pattern")
+	}
+}
+
+func TestRunConfigDefaultIsolation(t *testing.T) {
+	var run RunConfig
+	if run.GetIsolation() != IsolationTools {
+		t.Errorf("expected default isolation '%s', got '%s'", IsolationTools, run.GetIsolation())
+	}
+	if run.IsHermetic() {
+		t.Error("expected commands to inherit the environment by default")
 	}
 }
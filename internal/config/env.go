@@ -0,0 +1,185 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// envPlatforms maps the platform names used in [env.<platform>] tables to
+// runtime.GOOS values
+var envPlatforms = map[string]string{
+	"linux":   "linux",
+	"macos":   "darwin",
+	"windows": "windows",
+}
+
+// envName matches the variable names a shell can set
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// IsEnvName reports whether name is a valid environment variable name:
+// letters, digits and underscores, not starting with a digit
+func IsEnvName(name string) bool {
+	return envName.MatchString(name)
+}
+
+// EnvConfig is the [env] section: variables set for every tool cppenv runs,
+// with [env.linux], [env.macos] and [env.windows] tables that override them
+// on that platform
+//
+//	[env]
+//	CMAKE_BUILD_PARALLEL_LEVEL = "8"
+//	PATH = { prepend = ["${root}/tools"] }
+//
+//	[env.windows]
+//	CONAN_HOME = "${root}/.conan"
+type EnvConfig struct {
+	Vars map[string]EnvVar
+	// Platforms holds the overrides by platform name: linux, macos or windows
+	Platforms map[string]map[string]EnvVar
+}
+
+// EnvVar is a variable in the [env] section: a plain string value, or a
+// table that adds entries to a list variable such as PATH
+// Prepend and Append are joined with the platform's path list separator
+type EnvVar struct {
+	// Value replaces the inherited value if set
+	Value   *string  `toml:"value,omitempty"`
+	Prepend []string `toml:"prepend,omitempty"`
+	Append  []string `toml:"append,omitempty"`
+}
+
+// envVarTable has EnvVar's fields without its TOML methods, for decoding the table form
+type envVarTable EnvVar
+
+// UnmarshalTOML accepts `NAME = "value"` and `NAME = { prepend = [...], append = [...] }`
+func (v *EnvVar) UnmarshalTOML(data any) error {
+	switch value := data.(type) {
+	case string:
+		*v = EnvVar{Value: &value}
+		return nil
+	case map[string]any:
+		if err := decodeTable(value, (*envVarTable)(v)); err != nil {
+			return err
+		}
+		if v.Value == nil && len(v.Prepend) == 0 && len(v.Append) == 0 {
+			return fmt.Errorf("env table needs 'value', 'prepend' or 'append'")
+		}
+		return nil
+	default:
+		return fmt.Errorf("env value must be a string or a table, got %T", data)
+	}
+}
+
+// MarshalTOML writes plain values as strings
+func (v EnvVar) MarshalTOML() ([]byte, error) {
+	if v.Value != nil && len(v.Prepend) == 0 && len(v.Append) == 0 {
+		return []byte(quote(*v.Value)), nil
+	}
+	var fields []string
+	if v.Value != nil {
+		fields = append(fields, "value = "+quote(*v.Value))
+	}
+	if len(v.Prepend) > 0 {
+		fields = append(fields, "prepend = "+inlineArray(v.Prepend))
+	}
+	if len(v.Append) > 0 {
+		fields = append(fields, "append = "+inlineArray(v.Append))
+	}
+	return []byte("{ " + strings.Join(fields, ", ") + " }"), nil
+}
+
+// UnmarshalTOML splits platform tables from variables
+func (e *EnvConfig) UnmarshalTOML(data any) error {
+	table, ok := data.(map[string]any)
+	if !ok {
+		return fmt.Errorf("env must be a table, got %T", data)
+	}
+
+	*e = EnvConfig{Vars: make(map[string]EnvVar)}
+	for name, value := range table {
+		if sub, ok := value.(map[string]any); ok && envPlatforms[name] != "" && !isEnvVarTable(sub) {
+			vars := make(map[string]EnvVar)
+			for key, v := range sub {
+				var envVar EnvVar
+				if err := envVar.UnmarshalTOML(v); err != nil {
+					return fmt.Errorf("env.%s.%s: %w", name, key, err)
+				}
+				vars[key] = envVar
+			}
+			if e.Platforms == nil {
+				e.Platforms = make(map[string]map[string]EnvVar)
+			}
+			e.Platforms[name] = vars
+			continue
+		}
+
+		var envVar EnvVar
+		if err := envVar.UnmarshalTOML(value); err != nil {
+			return fmt.Errorf("env.%s: %w", name, err)
+		}
+		e.Vars[name] = envVar
+	}
+	return nil
+}
+
+// MarshalTOML writes the section as an inline table
+func (e EnvConfig) MarshalTOML() ([]byte, error) {
+	fields := marshalEnvVars(e.Vars)
+	for _, platform := range sortedNames(e.Platforms) {
+		fields = append(fields, platform+" = { "+strings.Join(marshalEnvVars(e.Platforms[platform]), ", ")+" }")
+	}
+	return []byte("{ " + strings.Join(fields, ", ") + " }"), nil
+}
+
+func marshalEnvVars(vars map[string]EnvVar) []string {
+	var fields []string
+	for _, name := range sortedNames(vars) {
+		value, _ := vars[name].MarshalTOML()
+		fields = append(fields, quote(name)+" = "+string(value))
+	}
+	return fields
+}
+
+// IsEmpty reports whether the section sets no variables
+func (e EnvConfig) IsEmpty() bool {
+	return len(e.Vars) == 0 && len(e.Platforms) == 0
+}
+
+// ForPlatform returns the variables for a runtime.GOOS value, with that
+// platform's overrides applied
+func (e EnvConfig) ForPlatform(goos string) map[string]EnvVar {
+	vars := make(map[string]EnvVar, len(e.Vars))
+	for name, v := range e.Vars {
+		vars[name] = v
+	}
+	for platform, overrides := range e.Platforms {
+		if envPlatforms[platform] != goos {
+			continue
+		}
+		for name, v := range overrides {
+			vars[name] = v
+		}
+	}
+	return vars
+}
+
+// isEnvVarTable reports whether a table is a variable rather than a platform table
+func isEnvVarTable(table map[string]any) bool {
+	for _, key := range []string{"value", "prepend", "append"} {
+		if _, ok := table[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadEnv(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "cppenv.toml")
+	content := `
+env-files = [".env", "${root}/.env.local"]
+
+[env]
+CMAKE_BUILD_PARALLEL_LEVEL = "8"
+CONAN_HOME = "${root}/.conan"
+PATH = { prepend = ["${root}/tools"], append = ["/opt/bin"] }
+
+[env.windows]
+CONAN_HOME = "C:/conan"
+
+[env.linux]
+linux = { value = "yes" }
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	root := filepath.Dir(configPath)
+
+	if len(cfg.EnvFiles) != 2 || cfg.EnvFiles[1] != root+"/.env.local" {
+		t.Errorf("expected interpolated env-files, got %v", cfg.EnvFiles)
+	}
+
+	linux := cfg.Env.ForPlatform("linux")
+	if *linux["CONAN_HOME"].Value != root+"/.conan" || *linux["linux"].Value != "yes" {
+		t.Errorf("expected linux variables, got %+v", linux)
+	}
+	path := linux["PATH"]
+	if path.Value != nil || len(path.Prepend) != 1 || path.Prepend[0] != root+"/tools" || path.Append[0] != "/opt/bin" {
+		t.Errorf("expected PATH prepend and append, got %+v", path)
+	}
+
+	windows := cfg.Env.ForPlatform("windows")
+	if *windows["CONAN_HOME"].Value != "C:/conan" {
+		t.Errorf("expected windows override, got %+v", windows["CONAN_HOME"])
+	}
+	if _, ok := windows["linux"]; ok {
+		t.Error("expected linux overrides not to apply on windows")
+	}
+	if len(cfg.Env.ForPlatform("darwin")) != 3 {
+		t.Errorf("expected base variables on macos, got %+v", cfg.Env.ForPlatform("darwin"))
+	}
+}
+
+func TestLoadEnvErrors(t *testing.T) {
+	tests := map[string]string{
+		"wrong type":     "[env]\nLEVEL = 8\n",
+		"empty table":    "[env]\nPATH = { }\n",
+		"unknown key":    "[env]\nPATH = { prepend = [\"a\"], before = [\"b\"] }\n",
+		"undefined var":  "[env]\nLEVEL = \"${vars.level}\"\n",
+		"platform value": "[env.macos]\nLEVEL = 8\n",
+		"bad env-files":  "env-files = \".env\"\n",
+		"bad name":       "[env]\n\"X; echo PWNED #\" = \"1\"\n",
+		"platform name":  "[env.linux]\n\"1ST\" = \"1\"\n",
+		"script name":    "[scripts.build]\ncmd = \"make\"\nenv = { \"A-B\" = \"1\" }\n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "cppenv.toml")
+			if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+				t.Fatalf("failed to write test config: %v", err)
+			}
+			if _, err := Load(configPath); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestWriteAndLoadEnv(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "cppenv.toml")
+	level := "8"
+
+	if err := Write(&Config{Project: ProjectConfig{Name: "empty"}}, configPath); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("failed to read written config: %v", err)
+	}
+	if strings.Contains(string(content), "env") {
+		t.Errorf("expected empty env to be omitted, got:\n%s", content)
+	}
+
+	original := &Config{
+		Project: ProjectConfig{Name: "roundtrip-test"},
+		Env: EnvConfig{
+			Vars: map[string]EnvVar{
+				"LEVEL": {Value: &level},
+				"PATH":  {Prepend: []string{"tools"}},
+			},
+			Platforms: map[string]map[string]EnvVar{
+				"macos": {"LEVEL": {Value: &level, Append: []string{"x"}}},
+			},
+		},
+	}
+	if err := Write(original, configPath); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	loaded, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	darwin := loaded.Env.ForPlatform("darwin")
+	if *darwin["LEVEL"].Value != "8" || darwin["LEVEL"].Append[0] != "x" || darwin["PATH"].Prepend[0] != "tools" {
+		t.Errorf("expected env to round-trip, got %+v", darwin)
+	}
+}
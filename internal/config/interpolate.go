@@ -0,0 +1,252 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// interpolator expands ${...} references in config values:
+//
+//	${project.name}          the project name
+//	${root}                  the directory containing cppenv.toml
+//	${cppenv.dir}            the project's .cppenv directory
+//	${os}, ${arch}           the platform, e.g. "linux" and "amd64"
+//	${vars.NAME}             a value from the [vars] section
+//	${env.NAME:-default}     an environment variable, with an optional default
+//
+// Other references, such as ${HOME}, are left for the shell, and "$${" is
+// written as a literal "${"
+type interpolator struct {
+	values map[string]string
+	vars   map[string]string
+	envRef func(name, fallback string, hasFallback bool) string
+	// expanding holds the vars being expanded, to detect cycles
+	expanding map[string]bool
+}
+
+// namespaces are the prefixes of references that must be defined
+var namespaces = []string{"project.", "cppenv.", "vars.", "env."}
+
+func newInterpolator(cfg *Config, target Target) *interpolator {
+	cppenvDir := filepath.Join(target.Root, ".cppenv")
+	if target.OS != runtime.GOOS {
+		// The project is set up elsewhere, so use that platform's separator
+		sep := "/"
+		if target.OS == "windows" {
+			sep = `\`
+		}
+		cppenvDir = strings.TrimRight(target.Root, `/\`) + sep + ".cppenv"
+	}
+	return &interpolator{
+		values: map[string]string{
+			"project.name": cfg.Project.Name,
+			"root":         target.Root,
+			"cppenv.dir":   cppenvDir,
+			"os":           target.OS,
+			"arch":         target.Arch,
+		},
+		vars:      cfg.Vars,
+		envRef:    target.EnvRef,
+		expanding: make(map[string]bool),
+	}
+}
+
+// expand replaces the references in s
+func (in *interpolator) expand(s string) (string, error) {
+	var out strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			out.WriteString(s)
+			return out.String(), nil
+		}
+		if i > 0 && s[i-1] == '$' {
+			out.WriteString(s[:i-1] + "${")
+			s = s[i+2:]
+			continue
+		}
+		out.WriteString(s[:i])
+
+		end := closingBrace(s, i+2)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated ${ in %q", s[i:])
+		}
+		ref := s[i+2 : end]
+		value, ok, err := in.lookup(ref)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			out.WriteString(value)
+		} else {
+			out.WriteString(s[i : end+1])
+		}
+		s = s[end+1:]
+	}
+}
+
+// lookup returns the value of a reference; ok is false for references that
+// are left for the shell
+func (in *interpolator) lookup(ref string) (value string, ok bool, err error) {
+	name, fallback, hasFallback := strings.Cut(ref, ":-")
+	if hasFallback {
+		if fallback, err = in.expand(fallback); err != nil {
+			return "", false, err
+		}
+	}
+
+	if value, ok := in.values[name]; ok {
+		return value, true, nil
+	}
+
+	switch {
+	case strings.HasPrefix(name, "env."):
+		if in.envRef != nil {
+			return in.envRef(strings.TrimPrefix(name, "env."), fallback, hasFallback), true, nil
+		}
+		value, set := os.LookupEnv(strings.TrimPrefix(name, "env."))
+		if set && (value != "" || !hasFallback) {
+			return value, true, nil
+		}
+	case strings.HasPrefix(name, "vars."):
+		key := strings.TrimPrefix(name, "vars.")
+		if raw, ok := in.vars[key]; ok {
+			if in.expanding[key] {
+				return "", false, fmt.Errorf("vars.%s refers to itself", key)
+			}
+			in.expanding[key] = true
+			defer delete(in.expanding, key)
+			value, err := in.expand(raw)
+			return value, err == nil, err
+		}
+	default:
+		if !hasNamespace(name) {
+			return "", false, nil
+		}
+	}
+
+	if hasFallback {
+		return fallback, true, nil
+	}
+	if strings.HasPrefix(name, "env.") {
+		return "", false, fmt.Errorf("environment variable %s is not set, use ${%s:-default} for a default", strings.TrimPrefix(name, "env."), name)
+	}
+	return "", false, fmt.Errorf("undefined variable ${%s}", name)
+}
+
+func hasNamespace(name string) bool {
+	for _, prefix := range namespaces {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// closingBrace returns the index of the } that closes a reference starting
+// at start, allowing references nested in defaults
+func closingBrace(s string, start int) int {
+	depth := 1
+	for i := start; i < len(s); i++ {
+		switch {
+		case s[i] == '{' && i > 0 && s[i-1] == '$':
+			depth++
+		case s[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// interpolate expands references in the scripts, tool post-install commands,
+// [env] and env-files for the target the config is used on
+func (c *Config) interpolate(target Target) error {
+	in := newInterpolator(c, target)
+
+	// A script that cannot be expanded, e.g. for an environment variable
+	// only set where it runs, only fails when it is run
+	for name, script := range c.Scripts {
+		script.err = in.expandScript("scripts."+name, &script)
+		c.Scripts[name] = script
+	}
+
+	for name, tool := range c.Tools {
+		if err := in.expandAll("tools."+name+".post-install", tool.PostInstall.Run); err != nil {
+			return err
+		}
+	}
+
+	expandEnv := func(field string, vars map[string]EnvVar) error {
+		for key, v := range vars {
+			if v.Value != nil {
+				expanded, err := in.expand(*v.Value)
+				if err != nil {
+					return fmt.Errorf("%s.%s: %w", field, key, err)
+				}
+				v.Value = &expanded
+			}
+			if err := in.expandAll(field+"."+key+".prepend", v.Prepend); err != nil {
+				return err
+			}
+			if err := in.expandAll(field+"."+key+".append", v.Append); err != nil {
+				return err
+			}
+			vars[key] = v
+		}
+		return nil
+	}
+	if err := expandEnv("env", c.Env.Vars); err != nil {
+		return err
+	}
+	for platform, vars := range c.Env.Platforms {
+		if err := expandEnv("env."+platform, vars); err != nil {
+			return err
+		}
+	}
+	return in.expandAll("env-files", c.EnvFiles)
+}
+
+// expandScript expands the references in a script named field, e.g.
+// "scripts.build"
+func (in *interpolator) expandScript(field string, script *Script) error {
+	fields := []*string{&script.Cmd, &script.Cwd}
+	for i := range script.Steps {
+		fields = append(fields, &script.Steps[i].Cmd)
+	}
+	for _, value := range fields {
+		expanded, err := in.expand(*value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+		*value = expanded
+	}
+	for key, value := range script.Env {
+		expanded, err := in.expand(value)
+		if err != nil {
+			return fmt.Errorf("%s.env.%s: %w", field, key, err)
+		}
+		script.Env[key] = expanded
+	}
+	if err := in.expandAll(field+".inputs", script.Inputs); err != nil {
+		return err
+	}
+	return in.expandAll(field+".outputs", script.Outputs)
+}
+
+// expandAll expands the references in values in place
+func (in *interpolator) expandAll(field string, values []string) error {
+	for i, value := range values {
+		expanded, err := in.expand(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+		values[i] = expanded
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestInterpolatorExpand(t *testing.T) {
+	t.Setenv("CPPENV_TEST_SET", "from-env")
+	t.Setenv("CPPENV_TEST_EMPTY", "")
+
+	cfg := &Config{
+		Project: ProjectConfig{Name: "demo"},
+		Vars: map[string]string{
+			"build": "${root}/build/${os}",
+			"flags": "-O2",
+		},
+	}
+	root := filepath.FromSlash("/work/demo")
+	in := newInterpolator(cfg, Target{Root: root, OS: runtime.GOOS, Arch: runtime.GOARCH})
+
+	tests := map[string]string{
+		"cmake -B ${vars.build}":                  "cmake -B " + root + "/build/" + runtime.GOOS,
+		"${project.name}-${arch}":                 "demo-" + runtime.GOARCH,
+		"${cppenv.dir}":                           filepath.Join(root, ".cppenv"),
+		"${env.CPPENV_TEST_SET}":                  "from-env",
+		"${env.CPPENV_TEST_EMPTY}":                "",
+		"${env.CPPENV_TEST_EMPTY:-fallback}":      "fallback",
+		"${env.CPPENV_TEST_UNSET:-${vars.flags}}": "-O2",
+		"echo ${HOME} $${vars.flags}":             "echo ${HOME} ${vars.flags}",
+	}
+	for input, expected := range tests {
+		got, err := in.expand(input)
+		if err != nil {
+			t.Errorf("expand(%q) failed: %v", input, err)
+			continue
+		}
+		if got != expected {
+			t.Errorf("expand(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+
+	for _, input := range []string{"${vars.missing}", "${project.version}", "${env.CPPENV_TEST_UNSET}", "${root"} {
+		if _, err := in.expand(input); err == nil {
+			t.Errorf("expand(%q): expected error", input)
+		}
+	}
+
+	cfg.Vars["loop"] = "${vars.loop}"
+	if _, err := newInterpolator(cfg, Target{Root: root, OS: runtime.GOOS, Arch: runtime.GOARCH}).expand("${vars.loop}"); err == nil {
+		t.Error("expected error for a var that refers to itself")
+	}
+}
+
+func TestLoadInterpolatesScripts(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "cppenv.toml")
+	content := `
+[project]
+name = "demo"
+
+[vars]
+build-dir = "build/${os}"
+
+[scripts]
+configure = "cmake -B ${vars.build-dir}"
+
+[scripts.build]
+cmd = "cmake --build ${vars.build-dir}"
+cwd = "${root}"
+env = { OUT = "${cppenv.dir}/out" }
+inputs = ["${vars.build-dir}/**"]
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	root, _ := filepath.Abs(dir)
+	if cfg.Scripts["configure"].Cmd != "cmake -B build/"+runtime.GOOS {
+		t.Errorf("unexpected configure cmd %q", cfg.Scripts["configure"].Cmd)
+	}
+	build := cfg.Scripts["build"]
+	if build.Cwd != root || build.Env["OUT"] != filepath.Join(root, ".cppenv")+"/out" || build.Inputs[0] != "build/"+runtime.GOOS+"/**" {
+		t.Errorf("expected cwd, env and inputs to be interpolated, got %+v", build)
+	}
+
+	content = strings.Replace(content, "${vars.build-dir}\"\ncwd", "${vars.typo}\"\ncwd", 1)
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+	// Only the script with the undefined variable fails, when it is run
+	cfg, err = Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if err := cfg.Scripts["build"].Err(); err == nil || !strings.Contains(err.Error(), "scripts.build") {
+		t.Errorf("expected undefined variable error naming the script, got %v", err)
+	}
+	if err := cfg.Scripts["configure"].Err(); err != nil {
+		t.Errorf("expected no error for configure, got %v", err)
+	}
+}
+
+func TestLoadAt(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "cppenv.toml")
+	content := `
+[env]
+OUT = "${cppenv.dir}/${os}-${arch}"
+HOST = "${env.CPPENV_TEST_SET:-none}"
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	t.Setenv("CPPENV_TEST_SET", "from-env")
+	envRef := func(name, fallback string, hasFallback bool) string {
+		return "<" + name + "|" + fallback + ">"
+	}
+	cfg, err := LoadAt(configPath, Target{Root: "/workspace", OS: "linux", Arch: "arm64", EnvRef: envRef})
+	if err != nil {
+		t.Fatalf("LoadAt() failed: %v", err)
+	}
+	if out := *cfg.Env.Vars["OUT"].Value; out != "/workspace/.cppenv/linux-arm64" {
+		t.Errorf("expected value for /workspace on linux/arm64, got %q", out)
+	}
+	if host := *cfg.Env.Vars["HOST"].Value; host != "<CPPENV_TEST_SET|none>" {
+		t.Errorf("expected the environment variable to be left to EnvRef, got %q", host)
+	}
+
+	cfg, err = LoadAt(configPath, Target{Root: `C:\src\demo`, OS: "windows", Arch: "amd64"})
+	if err != nil {
+		t.Fatalf("LoadAt() failed: %v", err)
+	}
+	if out := *cfg.Env.Vars["OUT"].Value; out != `C:\src\demo\.cppenv/windows-amd64` {
+		t.Errorf("expected value for C:\\src\\demo on windows, got %q", out)
+	}
+}
@@ -0,0 +1,267 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Script is an entry in the [scripts] section
+// It is either a command string, a list of steps, or a table:
+//
+//	[scripts]
+//	ci = ["configure", "build", "test"]
+//	lint = { steps = ["tidy", "check-format"], parallel = true }
+//
+//	[scripts.build]
+//	cmd = "cmake --build build"
+//	description = "Build the project"
+//	depends = ["configure"]
+//	cwd = "."
+//	env = { CMAKE_BUILD_PARALLEL_LEVEL = "8" }
+//	inputs = ["src/**/*.cpp", "CMakeLists.txt"]
+//	outputs = ["build/app"]
+//	shell = "bash"
+type Script struct {
+	Cmd string `toml:"cmd,omitempty"`
+	// Steps run in order instead of Cmd; see Step
+	Steps []Step `toml:"steps,omitempty"`
+	// KeepGoing runs the remaining steps after one fails
+	KeepGoing bool `toml:"keep-going,omitempty"`
+	// Parallel runs the steps concurrently instead of in order
+	Parallel    bool              `toml:"parallel,omitempty"`
+	Description string            `toml:"description,omitempty"`
+	Cwd         string            `toml:"cwd,omitempty"`
+	Env         map[string]string `toml:"env,omitempty"`
+	Depends     []string          `toml:"depends,omitempty"`
+	// Inputs and Outputs are globs relative to the project root; a script with
+	// inputs is skipped when they and its command are unchanged since its last
+	// successful run and its outputs exist
+	Inputs  []string `toml:"inputs,omitempty"`
+	Outputs []string `toml:"outputs,omitempty"`
+	// Shell is the shell the command runs in, e.g. "sh", "bash", "cmd" or
+	// "powershell", or "system" for sh or cmd depending on the platform;
+	// cppenv's built-in POSIX shell is used if empty
+	Shell string `toml:"shell,omitempty"`
+
+	// err is the error expanding the script's references, see Err
+	err error
+}
+
+// Step is one step of a multi-step script: either another script or a command
+// A plain string refers to a script if one has that name and is a command
+// otherwise; `{ script = "name" }` and `{ cmd = "..." }` are explicit
+type Step struct {
+	Script string `toml:"script,omitempty"`
+	Cmd    string `toml:"cmd,omitempty"`
+
+	// bare is set for steps given as plain strings until they are resolved
+	bare bool
+}
+
+// scriptTable has Script's fields without its TOML methods, for decoding the table form
+type scriptTable Script
+
+// UnmarshalTOML accepts `name = "command"`, `name = [steps]` and `[scripts.name]` tables
+func (s *Script) UnmarshalTOML(data any) error {
+	switch v := data.(type) {
+	case string:
+		*s = Script{Cmd: v}
+		return nil
+	case []any:
+		return s.UnmarshalTOML(map[string]any{"steps": v})
+	case map[string]any:
+		if err := decodeTable(v, (*scriptTable)(s)); err != nil {
+			return err
+		}
+		if s.Cmd == "" && len(s.Steps) == 0 {
+			return fmt.Errorf("script table needs 'cmd' or 'steps'")
+		}
+		if s.Cmd != "" && len(s.Steps) > 0 {
+			return fmt.Errorf("script table cannot have both 'cmd' and 'steps'")
+		}
+		if s.Parallel && len(s.Steps) == 0 {
+			return fmt.Errorf("'parallel' needs 'steps'")
+		}
+		if len(s.Inputs) > 0 && len(s.Steps) > 0 {
+			return fmt.Errorf("'inputs' cannot be used with 'steps'")
+		}
+		if len(s.Outputs) > 0 && len(s.Inputs) == 0 {
+			return fmt.Errorf("'outputs' needs 'inputs'")
+		}
+		return nil
+	default:
+		return fmt.Errorf("script must be a command string, a list of steps or a table, got %T", data)
+	}
+}
+
+// MarshalTOML writes plain command strings and step lists when there are no extra settings
+func (s Script) MarshalTOML() ([]byte, error) {
+	if s.IsSimple() {
+		if len(s.Steps) > 0 {
+			return []byte(inlineSteps(s.Steps)), nil
+		}
+		return []byte(quote(s.Cmd)), nil
+	}
+
+	var fields []string
+	if len(s.Steps) > 0 {
+		fields = append(fields, "steps = "+inlineSteps(s.Steps))
+	} else {
+		fields = append(fields, "cmd = "+quote(s.Cmd))
+	}
+	if s.KeepGoing {
+		fields = append(fields, "keep-going = true")
+	}
+	if s.Parallel {
+		fields = append(fields, "parallel = true")
+	}
+	if s.Description != "" {
+		fields = append(fields, "description = "+quote(s.Description))
+	}
+	if s.Cwd != "" {
+		fields = append(fields, "cwd = "+quote(s.Cwd))
+	}
+	if len(s.Env) > 0 {
+		fields = append(fields, "env = "+inlineMap(s.Env))
+	}
+	if len(s.Depends) > 0 {
+		fields = append(fields, "depends = "+inlineArray(s.Depends))
+	}
+	if len(s.Inputs) > 0 {
+		fields = append(fields, "inputs = "+inlineArray(s.Inputs))
+	}
+	if len(s.Outputs) > 0 {
+		fields = append(fields, "outputs = "+inlineArray(s.Outputs))
+	}
+	if s.Shell != "" {
+		fields = append(fields, "shell = "+quote(s.Shell))
+	}
+	return []byte("{ " + strings.Join(fields, ", ") + " }"), nil
+}
+
+// Err returns the error expanding the script's references at load time, such
+// as an unset environment variable; the script cannot run if it is set
+func (s Script) Err() error {
+	return s.err
+}
+
+// IsSimple reports whether the script is only a command or a list of steps
+func (s Script) IsSimple() bool {
+	return !s.KeepGoing && !s.Parallel && s.Description == "" && s.Cwd == "" && len(s.Env) == 0 &&
+		len(s.Depends) == 0 && len(s.Inputs) == 0 && len(s.Outputs) == 0 && s.Shell == ""
+}
+
+// stepTable has Step's fields without its TOML methods, for decoding the table form
+type stepTable Step
+
+// UnmarshalTOML accepts plain strings and `{ script = "..." }` or `{ cmd = "..." }` tables
+func (s *Step) UnmarshalTOML(data any) error {
+	switch v := data.(type) {
+	case string:
+		*s = Step{Cmd: v, bare: true}
+		return nil
+	case map[string]any:
+		if err := decodeTable(v, (*stepTable)(s)); err != nil {
+			return err
+		}
+		if (s.Script == "") == (s.Cmd == "") {
+			return fmt.Errorf("step table needs exactly one of 'script' or 'cmd'")
+		}
+		return nil
+	default:
+		return fmt.Errorf("step must be a string or a table, got %T", data)
+	}
+}
+
+// String returns the script name or command the step runs
+func (s Step) String() string {
+	if s.Script != "" {
+		return s.Script
+	}
+	return s.Cmd
+}
+
+// inlineSteps writes steps as an inline array, using plain strings where
+// they cannot be mistaken for the other kind of step
+func inlineSteps(steps []Step) string {
+	values := make([]string, len(steps))
+	for i, step := range steps {
+		switch {
+		case step.Script != "":
+			values[i] = quote(step.Script)
+		case strings.ContainsAny(step.Cmd, " \t"):
+			values[i] = quote(step.Cmd)
+		default:
+			values[i] = "{ cmd = " + quote(step.Cmd) + " }"
+		}
+	}
+	return "[" + strings.Join(values, ", ") + "]"
+}
+
+// resolveSteps turns plain-string steps that name a script into script steps
+func resolveSteps(scripts map[string]Script) {
+	for name, script := range scripts {
+		for i, step := range script.Steps {
+			if !step.bare {
+				continue
+			}
+			if _, ok := scripts[step.Cmd]; ok {
+				script.Steps[i] = Step{Script: step.Cmd}
+			} else {
+				script.Steps[i] = Step{Cmd: step.Cmd}
+			}
+		}
+		scripts[name] = script
+	}
+}
+
+// CheckScriptCycles reports a script that depends on or runs itself,
+// directly or through other scripts
+func CheckScriptCycles(scripts map[string]Script) error {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		if state[name] == done {
+			return nil
+		}
+		if state[name] == visiting {
+			for i, n := range path {
+				if n == name {
+					cycle := append(append([]string(nil), path[i:]...), name)
+					return fmt.Errorf("scripts: cycle %s", strings.Join(cycle, " -> "))
+				}
+			}
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+		script := scripts[name]
+		next := append([]string(nil), script.Depends...)
+		for _, step := range script.Steps {
+			if step.Script != "" {
+				next = append(next, step.Script)
+			}
+		}
+		for _, n := range next {
+			if err := visit(n); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		return nil
+	}
+
+	for _, name := range sortedNames(scripts) {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
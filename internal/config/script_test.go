@@ -0,0 +1,169 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadScriptTable(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "cppenv.toml")
+	content := `
+[scripts]
+configure = "cmake -B build"
+
+[scripts.build]
+cmd = "cmake --build build"
+description = "Build the project"
+cwd = "."
+env = { CMAKE_BUILD_PARALLEL_LEVEL = "8" }
+depends = ["configure"]
+shell = "bash"
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	if cfg.Scripts["configure"].Cmd != "cmake -B build" || !cfg.Scripts["configure"].IsSimple() {
+		t.Errorf("expected simple configure script, got %+v", cfg.Scripts["configure"])
+	}
+
+	build := cfg.Scripts["build"]
+	if build.Cmd != "cmake --build build" {
+		t.Errorf("expected build cmd 'cmake --build build', got '%s'", build.Cmd)
+	}
+	if build.Description != "Build the project" {
+		t.Errorf("expected description, got '%s'", build.Description)
+	}
+	if build.Cwd != "." || build.Shell != "bash" {
+		t.Errorf("expected cwd '.' and shell 'bash', got '%s' and '%s'", build.Cwd, build.Shell)
+	}
+	if build.Env["CMAKE_BUILD_PARALLEL_LEVEL"] != "8" {
+		t.Errorf("expected env to be set, got %v", build.Env)
+	}
+	if len(build.Depends) != 1 || build.Depends[0] != "configure" {
+		t.Errorf("expected depends [configure], got %v", build.Depends)
+	}
+}
+
+func TestLoadScriptSteps(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "cppenv.toml")
+	content := `
+[scripts]
+configure = "cmake -B build"
+build = "cmake --build build"
+ci = ["configure", "build", "ctest --test-dir build", { cmd = "configure" }]
+
+[scripts.all]
+steps = [{ script = "ci" }, "echo done"]
+keep-going = true
+
+[scripts.lint]
+steps = ["configure", "build"]
+parallel = true
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	expected := []Step{
+		{Script: "configure"},
+		{Script: "build"},
+		{Cmd: "ctest --test-dir build"},
+		{Cmd: "configure"},
+	}
+	ci := cfg.Scripts["ci"]
+	if len(ci.Steps) != len(expected) {
+		t.Fatalf("expected %d steps, got %+v", len(expected), ci.Steps)
+	}
+	for i, step := range ci.Steps {
+		if step != expected[i] {
+			t.Errorf("step %d: expected %+v, got %+v", i, expected[i], step)
+		}
+	}
+
+	all := cfg.Scripts["all"]
+	if !all.KeepGoing || len(all.Steps) != 2 || all.Steps[0].Script != "ci" || all.Steps[1].Cmd != "echo done" {
+		t.Errorf("expected all script with keep-going, got %+v", all)
+	}
+	if !cfg.Scripts["lint"].Parallel {
+		t.Error("expected lint script to be parallel")
+	}
+}
+
+func TestLoadScriptErrors(t *testing.T) {
+	tests := map[string]string{
+		"missing cmd":            "[scripts.build]\ndescription = \"Build\"\n",
+		"unknown key":            "[scripts.build]\ncmd = \"make\"\nunknown = 1\n",
+		"unknown dependency":     "[scripts.build]\ncmd = \"make\"\ndepends = [\"configure\"]\n",
+		"wrong type":             "[scripts]\nbuild = 1\n",
+		"cmd and steps":          "[scripts.ci]\ncmd = \"make\"\nsteps = [\"make test\"]\n",
+		"ambiguous step":         "[scripts]\nci = [{ script = \"a\", cmd = \"b\" }]\n",
+		"unknown step":           "[scripts]\nci = [{ script = \"build\" }]\n",
+		"parallel without steps": "[scripts.build]\ncmd = \"make\"\nparallel = true\n",
+		"outputs without inputs": "[scripts.gen]\ncmd = \"gen\"\noutputs = [\"out\"]\n",
+		"inputs with steps":      "[scripts.ci]\nsteps = [\"make\"]\ninputs = [\"src\"]\n",
+		"step cycle":             "[scripts]\nci = [\"check\"]\ncheck = [\"echo check\", \"ci\"]\n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "cppenv.toml")
+			if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+				t.Fatalf("failed to write test config: %v", err)
+			}
+			if _, err := Load(configPath); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestWriteAndLoadScriptTable(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "cppenv.toml")
+
+	original := &Config{
+		Project: ProjectConfig{Name: "roundtrip-test"},
+		Scripts: map[string]Script{
+			"configure": {Cmd: "cmake -B build"},
+			"build": {
+				Cmd:         "cmake --build build",
+				Description: "Build the project",
+				Env:         map[string]string{"LEVEL": "8"},
+				Depends:     []string{"configure"},
+			},
+		},
+	}
+
+	if err := Write(original, configPath); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("failed to read written config: %v", err)
+	}
+	if !strings.Contains(string(content), `configure = "cmake -B build"`) {
+		t.Errorf("expected simple scripts to be written as strings, got:\n%s", content)
+	}
+
+	loaded, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	build := loaded.Scripts["build"]
+	if build.Description != "Build the project" || build.Env["LEVEL"] != "8" || len(build.Depends) != 1 {
+		t.Errorf("expected build script to round-trip, got %+v", build)
+	}
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Tool is an entry in the [tools] section
+// It is either a plain version string or a table with extra install settings:
+//
+//	[tools.mytool]
+//	version = "1.0.0"
+//	expose = { mytool = "mytool/data/bin/mytool" }
+//
+//	[tools.mytool.post-install]
+//	run = ["mytool --download-data"]
+type Tool struct {
+	Version string `toml:"version" json:"version"`
+	// Expose maps binary names to paths relative to site-packages that are
+	// linked into the venv bin directory after install
+	Expose      map[string]string `toml:"expose,omitempty" json:"expose,omitempty"`
+	PostInstall PostInstall       `toml:"post-install,omitempty" json:"post-install,omitzero"`
+
+	// LLVM and Binaries are clang-tools settings: the LLVM major version to
+	// download and which of its binaries to install (all if empty)
+	LLVM     string   `toml:"llvm,omitempty" json:"llvm,omitempty"`
+	Binaries []string `toml:"binaries,omitempty" json:"binaries,omitempty"`
+}
+
+// DefaultLLVMVersion is the LLVM major version clang-tools installs by default
+const DefaultLLVMVersion = "19"
+
+// PostInstall lists commands run after a tool's package has been installed
+type PostInstall struct {
+	Run []string `toml:"run,omitempty" json:"run,omitempty"`
+}
+
+// toolTable has Tool's fields without its TOML methods, for decoding the table form
+type toolTable Tool
+
+// UnmarshalTOML accepts both `tool = "1.0"` and `[tools.tool]` tables
+func (t *Tool) UnmarshalTOML(data any) error {
+	switch v := data.(type) {
+	case string:
+		*t = Tool{Version: v}
+		return nil
+	case map[string]any:
+		if err := decodeTable(v, (*toolTable)(t)); err != nil {
+			return err
+		}
+		if t.Version == "" {
+			return fmt.Errorf("tool table is missing 'version'")
+		}
+		return nil
+	default:
+		return fmt.Errorf("tool must be a version string or a table, got %T", data)
+	}
+}
+
+// MarshalTOML writes plain version strings when there are no extra settings
+func (t Tool) MarshalTOML() ([]byte, error) {
+	if t.IsSimple() {
+		return []byte(quote(t.Version)), nil
+	}
+
+	fields := []string{"version = " + quote(t.Version)}
+	if len(t.Expose) > 0 {
+		fields = append(fields, "expose = "+inlineMap(t.Expose))
+	}
+	if len(t.PostInstall.Run) > 0 {
+		fields = append(fields, "post-install = { run = "+inlineArray(t.PostInstall.Run)+" }")
+	}
+	if t.LLVM != "" {
+		fields = append(fields, "llvm = "+quote(t.LLVM))
+	}
+	if len(t.Binaries) > 0 {
+		fields = append(fields, "binaries = "+inlineArray(t.Binaries))
+	}
+	return []byte("{ " + strings.Join(fields, ", ") + " }"), nil
+}
+
+// IsSimple reports whether the tool only pins a version
+func (t Tool) IsSimple() bool {
+	return len(t.Expose) == 0 && len(t.PostInstall.Run) == 0 && t.LLVM == "" && len(t.Binaries) == 0
+}
+
+// GetLLVMVersion returns the LLVM major version clang-tools should install
+func (t Tool) GetLLVMVersion() string {
+	if t.LLVM != "" {
+		return t.LLVM
+	}
+	return DefaultLLVMVersion
+}
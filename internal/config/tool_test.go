@@ -0,0 +1,149 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadToolTable(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "cppenv.toml")
+
+	content := `
+[project]
+name = "test-project"
+
+[tools]
+cmake = "3.28.1"
+
+[tools.mytool]
+version = "1.2.3"
+expose = { mytool = "mytool/bin/mytool" }
+
+[tools.mytool.post-install]
+run = ["mytool --setup"]
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	if cfg.Tools["cmake"].Version != "3.28.1" || !cfg.Tools["cmake"].IsSimple() {
+		t.Errorf("expected simple cmake 3.28.1, got %+v", cfg.Tools["cmake"])
+	}
+
+	tool := cfg.Tools["mytool"]
+	if tool.Version != "1.2.3" {
+		t.Errorf("expected mytool version '1.2.3', got '%s'", tool.Version)
+	}
+	if tool.Expose["mytool"] != "mytool/bin/mytool" {
+		t.Errorf("expected mytool to be exposed, got %v", tool.Expose)
+	}
+	if len(tool.PostInstall.Run) != 1 || tool.PostInstall.Run[0] != "mytool --setup" {
+		t.Errorf("expected one post-install command, got %v", tool.PostInstall.Run)
+	}
+}
+
+func TestLoadToolTableErrors(t *testing.T) {
+	tests := map[string]string{
+		"missing version": "[tools.mytool]\nexpose = { a = \"b\" }\n",
+		"unknown key":     "[tools.mytool]\nversion = \"1.0\"\nunknown = true\n",
+		"wrong type":      "[tools]\nmytool = 3\n",
+		"llvm on cmake":   "[tools.cmake]\nversion = \"3.28.1\"\nllvm = \"17\"\n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "cppenv.toml")
+			if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+				t.Fatalf("failed to write test config: %v", err)
+			}
+			if _, err := Load(configPath); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestWriteAndLoadToolTable(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "cppenv.toml")
+
+	original := &Config{
+		Project: ProjectConfig{Name: "roundtrip-test"},
+		Tools: map[string]Tool{
+			"cmake": {Version: "3.28.1"},
+			"mytool": {
+				Version:     "1.2.3",
+				Expose:      map[string]string{"mytool": "mytool/bin/mytool"},
+				PostInstall: PostInstall{Run: []string{`echo "done"`}},
+			},
+		},
+	}
+
+	if err := Write(original, configPath); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("failed to read written config: %v", err)
+	}
+	if !strings.Contains(string(content), `cmake = "3.28.1"`) {
+		t.Errorf("expected simple tools to be written as version strings, got:\n%s", content)
+	}
+
+	loaded, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	tool := loaded.Tools["mytool"]
+	if tool.Version != "1.2.3" {
+		t.Errorf("expected mytool version '1.2.3', got '%s'", tool.Version)
+	}
+	if tool.Expose["mytool"] != "mytool/bin/mytool" {
+		t.Errorf("expected mytool to be exposed, got %v", tool.Expose)
+	}
+	if len(tool.PostInstall.Run) != 1 || tool.PostInstall.Run[0] != `echo "done"` {
+		t.Errorf("expected post-install command to round-trip, got %v", tool.PostInstall.Run)
+	}
+}
+
+func TestLoadClangToolsSettings(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "cppenv.toml")
+	content := `
+[tools.clang-tools]
+version = "0.16.0"
+llvm = "17"
+binaries = ["clang-format", "clang-tidy"]
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	tool := cfg.Tools["clang-tools"]
+	if tool.GetLLVMVersion() != "17" {
+		t.Errorf("expected LLVM version '17', got '%s'", tool.GetLLVMVersion())
+	}
+	if len(tool.Binaries) != 2 {
+		t.Errorf("expected 2 binaries, got %v", tool.Binaries)
+	}
+}
+
+func TestGetLLVMVersionDefault(t *testing.T) {
+	tool := Tool{Version: "0.16.0"}
+	if tool.GetLLVMVersion() != DefaultLLVMVersion {
+		t.Errorf("expected default LLVM version '%s', got '%s'", DefaultLLVMVersion, tool.GetLLVMVersion())
+	}
+}
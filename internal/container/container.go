@@ -0,0 +1,267 @@
+// Package container generates a Dockerfile and a Dev Container configuration
+// that set the project environment up inside a container
+package container
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/michxymi/cppenv/internal/ci"
+	"github.com/michxymi/cppenv/internal/config"
+	"github.com/michxymi/cppenv/internal/environment"
+)
+
+const (
+	// Workspace is where the project is mounted in the container
+	Workspace = "/workspace"
+	// OS is the platform the container runs
+	OS = "linux"
+	// Home is CPPENV_HOME in the container, holding the managed Python
+	Home = "/opt/cppenv"
+)
+
+// Target is the container, to load the config with config.LoadAt for
+// Generate: ${arch} and ${env.NAME} become Dockerfile references, resolved
+// when the image is built, so the files do not depend on the host
+var Target = config.Target{
+	Root:   Workspace,
+	OS:     OS,
+	Arch:   dockerfileRef("${TARGETARCH}"),
+	EnvRef: envRef,
+}
+
+// refMark surrounds Dockerfile references in config values, which are
+// written as they are instead of quoted
+const refMark = "\x00"
+
+// dockerfileRef marks a Dockerfile variable reference in a config value
+func dockerfileRef(ref string) string {
+	return refMark + ref + refMark
+}
+
+// envRef turns ${env.NAME} into the build argument ${NAME}
+func envRef(name, fallback string, hasFallback bool) string {
+	if hasFallback {
+		// Nested references are kept, and their marks dropped
+		return dockerfileRef("${" + name + ":-" + dockerfileQuote(fallback, nil) + "}")
+	}
+	return dockerfileRef("${" + name + "}")
+}
+
+// refNames finds the variables a Dockerfile reference refers to, including
+// those in its default, but not escaped \${
+var refNames = regexp.MustCompile(`(?:^|[^\\])\$\{([A-Za-z_][A-Za-z0-9_]*)`)
+
+// Header starts every generated file
+const Header = "Generated by 'cppenv container generate' from cppenv.toml; run it again instead of editing this file."
+
+// Files generated, relative to the project root
+const (
+	DockerfilePath   = ".devcontainer/Dockerfile"
+	DevcontainerPath = ".devcontainer/devcontainer.json"
+)
+
+// File is a generated file
+type File struct {
+	Path    string
+	Content []byte
+}
+
+// Generate returns a Dockerfile that installs cppenv version, the managed
+// Python and the project's tools, and sets up the environment 'cppenv run'
+// gives commands, and a devcontainer.json that builds and uses it
+// The same inputs always give the same files
+func Generate(cfg *config.Config, version string) ([]File, error) {
+	dockerfile, err := generateDockerfile(cfg, version)
+	if err != nil {
+		return nil, err
+	}
+	devcontainer, err := generateDevcontainer(cfg, dockerfile)
+	if err != nil {
+		return nil, err
+	}
+	return []File{
+		{Path: DockerfilePath, Content: dockerfile},
+		{Path: DevcontainerPath, Content: devcontainer},
+	}, nil
+}
+
+func generateDockerfile(cfg *config.Config, version string) ([]byte, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, `# %s
+
+FROM golang:%s AS cppenv
+RUN %s
+
+FROM debian:bookworm
+RUN apt-get update \
+    && apt-get install -y --no-install-recommends ca-certificates git \
+    && rm -rf /var/lib/apt/lists/*
+COPY --from=cppenv /go/bin/cppenv /usr/local/bin/cppenv
+
+# The managed Python and the tools, at the versions pinned in cppenv.toml
+ENV CPPENV_HOME=%s
+WORKDIR %s
+COPY cppenv.toml ./
+RUN cppenv install
+
+# The environment 'cppenv run' starts commands in; env files are applied by
+# cppenv itself, as they are usually not committed
+`, Header, ci.GoVersion, ci.InstallCommand(version), Home, Workspace)
+
+	vars := cfg.Env.ForPlatform(OS)
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var env strings.Builder
+	args := make(map[string]bool)
+	for _, name := range names {
+		value, ok := envValue(name, vars[name], args)
+		if strings.Contains(value, "\n") {
+			return nil, fmt.Errorf("env.%s: a Dockerfile cannot set multi-line values", name)
+		}
+		if ok {
+			fmt.Fprintf(&env, "ENV %s=\"%s\"\n", name, value)
+		}
+	}
+	// Variables [env] refers to are build arguments: TARGETARCH, or those
+	// given with --build-arg, which are empty otherwise
+	for _, arg := range sortedKeys(args) {
+		fmt.Fprintf(&b, "ARG %s\n", arg)
+	}
+	b.WriteString(env.String())
+
+	bin := path.Join(Workspace, environment.VenvDir, environment.ExposedDir)
+	if cfg.Run.GetIsolation() == config.IsolationVenv {
+		bin = path.Join(Workspace, environment.VenvDir, "venv", "bin")
+	}
+	fmt.Fprintf(&b, "ENV PATH=\"%s:${PATH}\"\n", bin)
+	return []byte(b.String()), nil
+}
+
+// envValue returns the quoted value of an [env] variable for an ENV
+// instruction, referring to the variable's earlier value for prepend and
+// append without a value; ok is false if the variable is left unchanged
+// The variables its references refer to are added to args
+func envValue(name string, v config.EnvVar, args map[string]bool) (string, bool) {
+	quote := func(values []string) string {
+		return dockerfileQuote(strings.Join(values, ":"), args)
+	}
+	if v.Value != nil {
+		parts := append([]string(nil), v.Prepend...)
+		if *v.Value != "" {
+			parts = append(parts, *v.Value)
+		}
+		return quote(append(parts, v.Append...)), true
+	}
+
+	prepend, appended := quote(v.Prepend), quote(v.Append)
+	switch {
+	case prepend != "" && appended != "":
+		return fmt.Sprintf("%s${%s:+:${%s}}:%s", prepend, name, name, appended), true
+	case prepend != "":
+		return fmt.Sprintf("%s${%s:+:${%s}}", prepend, name, name), true
+	case appended != "":
+		return fmt.Sprintf("${%s:+${%s}:}%s", name, name, appended), true
+	default:
+		return "", false
+	}
+}
+
+// dockerfileQuote escapes a value for a double-quoted ENV instruction, which
+// would otherwise expand $ references, except for the references marked by
+// dockerfileRef; their variables are added to args unless it is nil
+func dockerfileQuote(s string, args map[string]bool) string {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`)
+	parts := strings.Split(s, refMark)
+	for i, part := range parts {
+		if i%2 == 0 {
+			parts[i] = escape.Replace(part)
+		} else if args != nil {
+			for _, match := range refNames.FindAllStringSubmatch(part, -1) {
+				args[match[1]] = true
+			}
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// devcontainer is the part of the devcontainer.json schema cppenv writes
+type devcontainer struct {
+	Name  string `json:"name"`
+	Build struct {
+		Dockerfile string `json:"dockerfile"`
+		Context    string `json:"context"`
+	} `json:"build"`
+	WorkspaceMount  string   `json:"workspaceMount"`
+	WorkspaceFolder string   `json:"workspaceFolder"`
+	Mounts          []string `json:"mounts"`
+}
+
+// generateDevcontainer returns a devcontainer.json that mounts the project
+// at Workspace
+// The project's .cppenv is a volume, so the environment installed in the
+// image shows through the project mount instead of the host's; its name
+// changes with the image and tools so a new volume starts from a new image
+func generateDevcontainer(cfg *config.Config, dockerfile []byte) ([]byte, error) {
+	tools, err := json.Marshal(cfg.Tools)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tools: %w", err)
+	}
+	sum := sha256.Sum256(append(append([]byte(nil), dockerfile...), tools...))
+	name := volumeName(cfg.Project.Name)
+
+	dc := devcontainer{
+		Name:            cfg.Project.Name,
+		WorkspaceMount:  "source=${localWorkspaceFolder},target=" + Workspace + ",type=bind",
+		WorkspaceFolder: Workspace,
+		Mounts: []string{
+			fmt.Sprintf("source=%s-cppenv-%s,target=%s,type=volume", name, hex.EncodeToString(sum[:6]), path.Join(Workspace, environment.VenvDir)),
+		},
+	}
+	dc.Build.Dockerfile = path.Base(DockerfilePath)
+	dc.Build.Context = ".."
+
+	content, err := json.MarshalIndent(dc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode devcontainer.json: %w", err)
+	}
+	// devcontainer.json allows comments
+	return []byte("// " + Header + "\n" + string(content) + "\n"), nil
+}
+
+// volumeName turns a project name into a valid Docker volume name prefix
+func volumeName(project string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '.', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		default:
+			return '-'
+		}
+	}, project)
+	name = strings.Trim(name, "-_.")
+	if name == "" {
+		return "project"
+	}
+	return name
+}
@@ -0,0 +1,152 @@
+package container
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/michxymi/cppenv/internal/config"
+)
+
+func TestGenerate(t *testing.T) {
+	home, price := "/workspace/.conan", `$5 "each"`
+	cfg := &config.Config{
+		Project: config.ProjectConfig{Name: "My Project"},
+		Tools:   map[string]config.Tool{"cmake": {Version: "3.28.1"}},
+		Env: config.EnvConfig{
+			Vars: map[string]config.EnvVar{
+				"CONAN_HOME": {Value: &home},
+				"PRICE":      {Value: &price},
+				"PKG_PATH":   {Prepend: []string{"/workspace/pc"}},
+				"LIBS":       {Append: []string{"/opt/lib"}},
+			},
+			Platforms: map[string]map[string]config.EnvVar{
+				"windows": {"WINDOWS_ONLY": {Value: &home}},
+			},
+		},
+	}
+
+	files, err := Generate(cfg, "v1.2.3")
+	if err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+	if len(files) != 2 || files[0].Path != DockerfilePath || files[1].Path != DevcontainerPath {
+		t.Fatalf("expected Dockerfile and devcontainer.json, got %+v", files)
+	}
+
+	dockerfile := string(files[0].Content)
+	for _, line := range []string{
+		"RUN go install github.com/michxymi/cppenv@v1.2.3\n",
+		"ENV CPPENV_HOME=/opt/cppenv\n",
+		"RUN cppenv install\n",
+		`ENV CONAN_HOME="/workspace/.conan"` + "\n",
+		`ENV PRICE="\$5 \"each\""` + "\n",
+		`ENV PKG_PATH="/workspace/pc${PKG_PATH:+:${PKG_PATH}}"` + "\n",
+		`ENV LIBS="${LIBS:+${LIBS}:}/opt/lib"` + "\n",
+		`ENV PATH="/workspace/.cppenv/bin:${PATH}"` + "\n",
+	} {
+		if !strings.Contains(dockerfile, line) {
+			t.Errorf("expected %q in Dockerfile, got:\n%s", line, dockerfile)
+		}
+	}
+	if strings.Contains(dockerfile, "WINDOWS_ONLY") {
+		t.Error("expected only Linux variables")
+	}
+	if !strings.HasSuffix(dockerfile, `ENV PATH="/workspace/.cppenv/bin:${PATH}"`+"\n") {
+		t.Error("expected PATH to be set last, after [env]")
+	}
+
+	// devcontainer.json is JSON after the header comment
+	_, content, _ := strings.Cut(string(files[1].Content), "\n")
+	var dc devcontainer
+	if err := json.Unmarshal([]byte(content), &dc); err != nil {
+		t.Fatalf("failed to parse devcontainer.json: %v\n%s", err, files[1].Content)
+	}
+	if dc.Build.Dockerfile != "Dockerfile" || dc.WorkspaceFolder != Workspace || len(dc.Mounts) != 1 ||
+		!strings.HasPrefix(dc.Mounts[0], "source=my-project-cppenv-") || !strings.Contains(dc.Mounts[0], "target=/workspace/.cppenv,") {
+		t.Errorf("unexpected devcontainer.json:\n%s", files[1].Content)
+	}
+
+	again, _ := Generate(cfg, "v1.2.3")
+	for i := range files {
+		if string(again[i].Content) != string(files[i].Content) {
+			t.Errorf("expected the same %s every time", files[i].Path)
+		}
+	}
+
+	// A new tool version gets a new volume, filled from the new image
+	cfg.Tools["cmake"] = config.Tool{Version: "3.29.0"}
+	changed, _ := Generate(cfg, "v1.2.3")
+	if string(changed[1].Content) == string(files[1].Content) {
+		t.Error("expected devcontainer.json to change with the tools")
+	}
+
+	cfg.Run.Isolation = config.IsolationVenv
+	venv, _ := Generate(cfg, "v1.2.3")
+	if !strings.Contains(string(venv[0].Content), `ENV PATH="/workspace/.cppenv/venv/bin:${PATH}"`) {
+		t.Errorf("expected the venv bin directory on PATH, got:\n%s", venv[0].Content)
+	}
+
+	multiline := "a\nb"
+	cfg.Env.Vars["MULTI"] = config.EnvVar{Value: &multiline}
+	if _, err := Generate(cfg, "v1.2.3"); err == nil {
+		t.Error("expected error for a multi-line value")
+	}
+}
+
+func TestGenerateIndependentOfHost(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "cppenv.toml")
+	content := `
+[project]
+name = "demo"
+
+[env]
+SDK = "${env.CPPENV_TEST_SDK}/lib"
+MODE = "${env.CPPENV_TEST_MODE:-release}"
+TRIPLE = "${arch}-linux-gnu"
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	generate := func() []File {
+		t.Helper()
+		cfg, err := config.LoadAt(configPath, Target)
+		if err != nil {
+			t.Fatalf("LoadAt() failed: %v", err)
+		}
+		files, err := Generate(cfg, "v1.2.3")
+		if err != nil {
+			t.Fatalf("Generate() failed: %v", err)
+		}
+		return files
+	}
+
+	t.Setenv("CPPENV_TEST_SDK", "/home/me/sdk")
+	t.Setenv("CPPENV_TEST_MODE", "debug")
+	files := generate()
+	dockerfile := string(files[0].Content)
+	if strings.Contains(dockerfile, "/home/me") || strings.Contains(dockerfile, "debug") {
+		t.Errorf("expected no values from the host, got:\n%s", dockerfile)
+	}
+	for _, line := range []string{
+		"ARG CPPENV_TEST_MODE\nARG CPPENV_TEST_SDK\nARG TARGETARCH\n",
+		`ENV MODE="${CPPENV_TEST_MODE:-release}"` + "\n",
+		`ENV SDK="${CPPENV_TEST_SDK}/lib"` + "\n",
+		`ENV TRIPLE="${TARGETARCH}-linux-gnu"` + "\n",
+	} {
+		if !strings.Contains(dockerfile, line) {
+			t.Errorf("expected %q in Dockerfile, got:\n%s", line, dockerfile)
+		}
+	}
+
+	os.Unsetenv("CPPENV_TEST_SDK")
+	t.Setenv("CPPENV_TEST_MODE", "")
+	for i, file := range generate() {
+		if string(file.Content) != string(files[i].Content) {
+			t.Errorf("expected %s not to depend on the environment", file.Path)
+		}
+	}
+}
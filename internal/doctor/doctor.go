@@ -0,0 +1,75 @@
+package doctor
+
+import "fmt"
+
+// Status is the outcome of a single diagnostic check
+type Status int
+
+const (
+	Pass Status = iota
+	Skip
+	Warn
+	Fail
+)
+
+func (s Status) String() string {
+	switch s {
+	case Pass:
+		return "pass"
+	case Skip:
+		return "skip"
+	case Warn:
+		return "warn"
+	case Fail:
+		return "fail"
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+// Result describes what a check found
+// Hint tells the user how to resolve a warning or failure
+type Result struct {
+	Status  Status
+	Message string
+	Hint    string
+}
+
+// Check is a single diagnostic
+// Fix is nil for problems that cannot be repaired automatically
+type Check struct {
+	Category string
+	Name     string
+	Run      func() Result
+	Fix      func() error
+}
+
+// registry lists the check groups in the order they run
+var registry = [][]Check{
+	pythonChecks,
+	environmentChecks,
+}
+
+// Checks returns all registered checks in run order
+func Checks() []Check {
+	var checks []Check
+	for _, group := range registry {
+		checks = append(checks, group...)
+	}
+	return checks
+}
+
+func pass(format string, args ...any) Result {
+	return Result{Status: Pass, Message: fmt.Sprintf(format, args...)}
+}
+
+func skip(format string, args ...any) Result {
+	return Result{Status: Skip, Message: fmt.Sprintf(format, args...)}
+}
+
+func warn(hint, format string, args ...any) Result {
+	return Result{Status: Warn, Message: fmt.Sprintf(format, args...), Hint: hint}
+}
+
+func fail(hint, format string, args ...any) Result {
+	return Result{Status: Fail, Message: fmt.Sprintf(format, args...), Hint: hint}
+}
@@ -0,0 +1,66 @@
+package doctor
+
+import (
+	"testing"
+)
+
+func TestChecksRegistered(t *testing.T) {
+	checks := Checks()
+	if len(checks) == 0 {
+		t.Fatal("expected registered checks, got none")
+	}
+
+	seen := make(map[string]bool)
+	for _, check := range checks {
+		if check.Category == "" || check.Name == "" {
+			t.Errorf("check has empty category or name: %+v", check)
+		}
+		if check.Run == nil {
+			t.Errorf("check %q has no Run function", check.Name)
+		}
+		if seen[check.Name] {
+			t.Errorf("duplicate check name %q", check.Name)
+		}
+		seen[check.Name] = true
+	}
+}
+
+func TestChecksGroupedByCategory(t *testing.T) {
+	// Categories must be contiguous so output headers are printed once
+	done := make(map[string]bool)
+	current := ""
+	for _, check := range Checks() {
+		if check.Category == current {
+			continue
+		}
+		if done[check.Category] {
+			t.Errorf("category %q is split across the registry", check.Category)
+		}
+		done[current] = true
+		current = check.Category
+	}
+}
+
+func TestStatusString(t *testing.T) {
+	tests := map[Status]string{
+		Pass: "pass",
+		Skip: "skip",
+		Warn: "warn",
+		Fail: "fail",
+	}
+	for status, want := range tests {
+		if got := status.String(); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+}
+
+func TestSamePath(t *testing.T) {
+	dir := t.TempDir()
+	if !samePath(dir, dir+"/") {
+		t.Errorf("expected %s and %s/ to be the same path", dir, dir)
+	}
+	if samePath(dir, dir+"/other") {
+		t.Error("expected different paths to differ")
+	}
+}
@@ -0,0 +1,140 @@
+package doctor
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/michxymi/cppenv/internal/environment"
+	"github.com/michxymi/cppenv/internal/python"
+)
+
+var environmentChecks = []Check{
+	{
+		Category: "Environment",
+		Name:     "virtual environment exists",
+		Run:      checkVenvExists,
+	},
+	{
+		Category: "Environment",
+		Name:     "venv uses managed Python",
+		Run:      checkVenvPython,
+	},
+	{
+		Category: "Environment",
+		Name:     "zig wrappers",
+		Run:      checkZigWrappers,
+		Fix:      repairZig,
+	},
+	{
+		Category: "Environment",
+		Name:     ".cppenv ignored by git",
+		Run:      checkGitignore,
+		Fix:      addToGitignore,
+	},
+	{
+		Category: "Environment",
+		Name:     "conan profile",
+		Run:      checkConanProfile,
+		Fix:      detectConanProfile,
+	},
+}
+
+func checkVenvExists() Result {
+	if !environment.Exists() {
+		return fail("run 'cppenv install'", "not found at %s", environment.GetVenvPath())
+	}
+	return pass("%s", environment.GetVenvPath())
+}
+
+func checkVenvPython() Result {
+	if !environment.Exists() {
+		return skip("no virtual environment")
+	}
+	cfg, err := environment.ReadVenvConfig()
+	if err != nil {
+		return fail("remove .cppenv/venv and run 'cppenv install'", "cannot read pyvenv.cfg: %v", err)
+	}
+	want := filepath.Dir(python.GetPythonPath())
+	if !samePath(cfg["home"], want) {
+		return warn("remove .cppenv/venv and run 'cppenv install'", "created by Python in %s, expected %s", cfg["home"], want)
+	}
+	return pass("Python %s", cfg["version"])
+}
+
+func checkZigWrappers() Result {
+	ccPath, cxxPath := environment.GetZigWrapperPaths()
+	if _, err := os.Stat(ccPath); os.IsNotExist(err) {
+		if _, ok := environment.LookupTool("zig"); ok {
+			return fail("run 'cppenv doctor --fix'", "zig is installed but %s is missing", filepath.Base(ccPath))
+		}
+		return skip("zig not installed")
+	}
+	for _, wrapper := range []string{ccPath, cxxPath} {
+		target, err := environment.ReadWrapperTarget(wrapper)
+		if err != nil {
+			return fail("run 'cppenv doctor --fix'", "%v", err)
+		}
+		if _, err := os.Stat(target); err != nil {
+			return fail("run 'cppenv doctor --fix' or reinstall ziglang with 'cppenv install'",
+				"%s points at missing %s", filepath.Base(wrapper), target)
+		}
+	}
+	return pass("zig-cc and zig-c++ point at a valid zig")
+}
+
+func repairZig() error {
+	return environment.RepairTool("ziglang")
+}
+
+func checkGitignore() Result {
+	ignored, err := environment.IsGitignored()
+	if err != nil {
+		return warn("", "could not check: %v", err)
+	}
+	if !ignored {
+		return fail("add .cppenv/ to .gitignore or run 'cppenv doctor --fix'", ".cppenv/ would be committed")
+	}
+	return pass(".cppenv/ is ignored")
+}
+
+func addToGitignore() error {
+	_, err := environment.AddToGitignore()
+	return err
+}
+
+func checkConanProfile() Result {
+	conan, ok := environment.LookupTool("conan")
+	if !ok {
+		return skip("conan not installed")
+	}
+	cmd := exec.Command(conan, "profile", "path", "default")
+	cmd.Env = environment.GetActivatedEnv()
+	out, err := cmd.Output()
+	if err != nil {
+		return fail("run 'cppenv run conan profile detect' or 'cppenv doctor --fix'", "no default profile found")
+	}
+	return pass("%s", strings.TrimSpace(string(out)))
+}
+
+func detectConanProfile() error {
+	conan, ok := environment.LookupTool("conan")
+	if !ok {
+		return nil
+	}
+	cmd := exec.Command(conan, "profile", "detect", "--force")
+	cmd.Env = environment.GetActivatedEnv()
+	return cmd.Run()
+}
+
+// samePath compares two paths after cleaning and resolving symlinks
+func samePath(a, b string) bool {
+	if resolved, err := filepath.EvalSymlinks(a); err == nil {
+		a = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(b); err == nil {
+		b = resolved
+	}
+	return filepath.Clean(a) == filepath.Clean(b)
+}
@@ -0,0 +1,48 @@
+package doctor
+
+import (
+	"github.com/michxymi/cppenv/internal/python"
+)
+
+var pythonChecks = []Check{
+	{
+		Category: "Python",
+		Name:     "managed Python installed",
+		Run:      checkPythonInstalled,
+		Fix:      python.Install,
+	},
+	{
+		Category: "Python",
+		Name:     "managed Python runs",
+		Run:      checkPythonRuns,
+		Fix:      reinstallPython,
+	},
+}
+
+func checkPythonInstalled() Result {
+	if !python.IsInstalled() {
+		return fail("run 'cppenv install' or 'cppenv doctor --fix'", "not found at %s", python.GetPythonPath())
+	}
+	return pass("%s", python.GetPythonPath())
+}
+
+func checkPythonRuns() Result {
+	if !python.IsInstalled() {
+		return skip("managed Python not installed")
+	}
+	version, err := python.InstalledVersion()
+	if err != nil {
+		return fail("reinstall with 'cppenv doctor --fix'", "%v", err)
+	}
+	if version != python.PythonVersion {
+		return warn("reinstall with 'cppenv doctor --fix'", "reports %s, expected %s", version, python.PythonVersion)
+	}
+	return pass("Python %s", version)
+}
+
+func reinstallPython() error {
+	if err := python.Remove(); err != nil {
+		return err
+	}
+	return python.Install()
+}
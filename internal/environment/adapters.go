@@ -0,0 +1,261 @@
+package environment
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/michxymi/cppenv/internal/config"
+)
+
+// toolAdapter performs extra install steps for a tool after pip has installed
+// its package, returning the paths it created so they can be removed when the
+// tool is uninstalled or reinstalled
+type toolAdapter func(name string, tool config.Tool) ([]string, error)
+
+// builtinAdapters handle packages that need setup beyond pip install
+var builtinAdapters = map[string]toolAdapter{
+	"ziglang":     setupZig,
+	"clang-tools": setupClangTools,
+}
+
+// runAdapters runs the built-in adapter for a tool, followed by the expose and
+// post-install steps declared in cppenv.toml
+// Artifacts created before a failure are returned along with the error
+func runAdapters(name string, tool config.Tool) ([]string, error) {
+	adapters := []toolAdapter{exposeBinaries, runPostInstall}
+	if builtin, ok := builtinAdapters[name]; ok {
+		adapters = append([]toolAdapter{builtin}, adapters...)
+	}
+
+	var artifacts []string
+	for _, adapter := range adapters {
+		created, err := adapter(name, tool)
+		artifacts = append(artifacts, created...)
+		if err != nil {
+			return artifacts, err
+		}
+	}
+	return artifacts, nil
+}
+
+// GetSitePackagesPath returns the venv's site-packages directory
+func GetSitePackagesPath() (string, error) {
+	venvPath := GetVenvPath()
+	if runtime.GOOS == "windows" {
+		return filepath.Join(venvPath, "Lib", "site-packages"), nil
+	}
+
+	// The directory is named after the Python version, e.g. lib/python3.11
+	matches, _ := filepath.Glob(filepath.Join(venvPath, "lib", "python*", "site-packages"))
+	if len(matches) == 0 {
+		return "", fmt.Errorf("site-packages not found in %s", venvPath)
+	}
+	sort.Strings(matches)
+	return matches[len(matches)-1], nil
+}
+
+// exeName appends .exe to a binary name on Windows
+func exeName(name string) string {
+	if runtime.GOOS == "windows" && !strings.HasSuffix(strings.ToLower(name), ".exe") {
+		return name + ".exe"
+	}
+	return name
+}
+
+// linkBinary links target to source, replacing whatever is at target
+// Falls back to a hard link where symlinks are not permitted (e.g. Windows
+// without developer mode)
+func linkBinary(source, target string) error {
+	os.Remove(target)
+	if err := os.Symlink(source, target); err != nil {
+		if linkErr := os.Link(source, target); linkErr != nil {
+			return fmt.Errorf("failed to link %s: %w", target, err)
+		}
+	}
+	return nil
+}
+
+// exposeBinaries links the binaries declared in a tool's expose table into bin/
+func exposeBinaries(name string, tool config.Tool) ([]string, error) {
+	if len(tool.Expose) == 0 {
+		return nil, nil
+	}
+	sitePackages, err := GetSitePackagesPath()
+	if err != nil {
+		return nil, err
+	}
+
+	binNames := make([]string, 0, len(tool.Expose))
+	for binName := range tool.Expose {
+		binNames = append(binNames, binName)
+	}
+	sort.Strings(binNames)
+
+	var created []string
+	for _, binName := range binNames {
+		source := filepath.Join(sitePackages, filepath.FromSlash(tool.Expose[binName]))
+		if _, err := os.Stat(source); err != nil {
+			return created, fmt.Errorf("cannot expose %s: %s not found", binName, source)
+		}
+		target := filepath.Join(GetBinPath(), filepath.Base(exeName(binName)))
+		if err := linkBinary(source, target); err != nil {
+			return created, err
+		}
+		created = append(created, target)
+	}
+	return created, nil
+}
+
+// runPostInstall runs a tool's post-install commands
+// The commands run with the activated environment plus CPPENV_VENV, CPPENV_BIN
+// and CPPENV_SITE_PACKAGES; files they add to bin/ are recorded as artifacts
+func runPostInstall(name string, tool config.Tool) ([]string, error) {
+	if len(tool.PostInstall.Run) == 0 {
+		return nil, nil
+	}
+
+	env := append(GetActivatedEnv(),
+		"CPPENV_VENV="+GetVenvPath(),
+		"CPPENV_BIN="+GetBinPath(),
+	)
+	if sitePackages, err := GetSitePackagesPath(); err == nil {
+		env = append(env, "CPPENV_SITE_PACKAGES="+sitePackages)
+	}
+
+	before := listDir(GetBinPath())
+	var err error
+	for _, script := range tool.PostInstall.Run {
+		args := ShellArgs(script)
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Env = env
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if runErr := cmd.Run(); runErr != nil {
+			err = fmt.Errorf("post-install command %q failed: %w", script, runErr)
+			break
+		}
+	}
+	return newEntries(before, GetBinPath()), err
+}
+
+// ShellArgs returns the command line that runs script with the system shell
+func ShellArgs(script string) []string {
+	if runtime.GOOS == "windows" {
+		return []string{"cmd", "/c", script}
+	}
+	return []string{"sh", "-c", script}
+}
+
+// listDir returns the set of names in a directory
+func listDir(dir string) map[string]bool {
+	names := make(map[string]bool)
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		names[entry.Name()] = true
+	}
+	return names
+}
+
+// newEntries returns the paths in dir whose names are not in before
+func newEntries(before map[string]bool, dir string) []string {
+	var created []string
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if !before[entry.Name()] {
+			created = append(created, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return created
+}
+
+// setupZig links the zig binary, which the ziglang package keeps in
+// site-packages/ziglang, into bin/ and creates the zig-cc and zig-c++ wrappers
+func setupZig(name string, tool config.Tool) ([]string, error) {
+	sitePackages, err := GetSitePackagesPath()
+	if err != nil {
+		return nil, err
+	}
+	zigSource := filepath.Join(sitePackages, "ziglang", exeName("zig"))
+	if _, err := os.Stat(zigSource); err != nil {
+		return nil, fmt.Errorf("zig binary not found at %s", zigSource)
+	}
+
+	zigTarget := filepath.Join(GetBinPath(), exeName("zig"))
+	if err := linkBinary(zigSource, zigTarget); err != nil {
+		return nil, err
+	}
+	created := []string{zigTarget}
+
+	// Create zig-cc and zig-c++ wrapper scripts for CMake/Conan compatibility
+	// Place them in .cppenv directory, not in venv/bin
+	cppenvDir := GetCppenvDir()
+	if err := os.MkdirAll(cppenvDir, 0755); err != nil {
+		return created, fmt.Errorf("failed to create .cppenv directory: %w", err)
+	}
+	wrappers, err := createZigWrapperScripts(cppenvDir, zigTarget)
+	return append(created, wrappers...), err
+}
+
+// createZigWrapperScripts creates zig-cc and zig-c++ wrapper scripts
+// These are needed because CMake/Conan need to invoke "zig cc" and "zig c++"
+// but they expect a single executable path
+// targetDir is the directory where the wrappers should be created (.cppenv directory)
+func createZigWrapperScripts(targetDir, zigPath string) ([]string, error) {
+	// Convert to forward slashes for shell scripts
+	zigPath = strings.ReplaceAll(zigPath, "\\", "/")
+
+	var ccName, cxxName, ccContent, cxxContent string
+	if runtime.GOOS == "windows" {
+		// Create batch files on Windows
+		ccName, cxxName = "zig-cc.bat", "zig-c++.bat"
+		ccContent = fmt.Sprintf("@echo off\r\n\"%s\" cc %%*\r\n", zigPath)
+		cxxContent = fmt.Sprintf("@echo off\r\n\"%s\" c++ %%*\r\n", zigPath)
+	} else {
+		// Create shell scripts on Unix
+		ccName, cxxName = "zig-cc", "zig-c++"
+		ccContent = fmt.Sprintf("#!/bin/sh\nexec \"%s\" cc \"$@\"\n", zigPath)
+		cxxContent = fmt.Sprintf("#!/bin/sh\nexec \"%s\" c++ \"$@\"\n", zigPath)
+	}
+
+	ccPath := filepath.Join(targetDir, ccName)
+	cxxPath := filepath.Join(targetDir, cxxName)
+	if err := os.WriteFile(ccPath, []byte(ccContent), 0755); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", ccName, err)
+	}
+	if err := os.WriteFile(cxxPath, []byte(cxxContent), 0755); err != nil {
+		return []string{ccPath}, fmt.Errorf("failed to write %s: %w", cxxName, err)
+	}
+	return []string{ccPath, cxxPath}, nil
+}
+
+// setupClangTools runs 'clang-tools --install' to download clang binaries
+// for the LLVM version configured for the tool (19 by default)
+func setupClangTools(name string, tool config.Tool) ([]string, error) {
+	binPath := GetBinPath()
+	clangToolsPath := filepath.Join(binPath, exeName("clang-tools"))
+
+	// Check if clang-tools CLI exists
+	if _, err := os.Stat(clangToolsPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("clang-tools CLI not found at %s", clangToolsPath)
+	}
+
+	args := []string{"--install", tool.GetLLVMVersion(), "--directory", binPath}
+	if len(tool.Binaries) > 0 {
+		args = append(args, "--tool")
+		args = append(args, tool.Binaries...)
+	}
+
+	before := listDir(binPath)
+	cmd := exec.Command(clangToolsPath, args...)
+	cmd.Env = GetActivatedEnv()
+	if err := cmd.Run(); err != nil {
+		return newEntries(before, binPath), fmt.Errorf("failed to run clang-tools --install: %w", err)
+	}
+
+	return newEntries(before, binPath), nil
+}
@@ -0,0 +1,206 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/michxymi/cppenv/internal/config"
+)
+
+// chdirTemp changes into a new temporary directory for the duration of the test
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	origWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(origWd) })
+
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+	return dir
+}
+
+// makeFakeVenv creates the venv directories adapters expect and returns site-packages
+func makeFakeVenv(t *testing.T) string {
+	t.Helper()
+	if err := os.MkdirAll(GetBinPath(), 0755); err != nil {
+		t.Fatalf("failed to create bin directory: %v", err)
+	}
+	sitePackages := filepath.Join(GetVenvPath(), "lib", "python3.11", "site-packages")
+	if runtime.GOOS == "windows" {
+		sitePackages = filepath.Join(GetVenvPath(), "Lib", "site-packages")
+	}
+	if err := os.MkdirAll(sitePackages, 0755); err != nil {
+		t.Fatalf("failed to create site-packages: %v", err)
+	}
+	return sitePackages
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestGetSitePackagesPath(t *testing.T) {
+	chdirTemp(t)
+
+	if _, err := GetSitePackagesPath(); err == nil && runtime.GOOS != "windows" {
+		t.Error("expected error when venv does not exist")
+	}
+
+	want := makeFakeVenv(t)
+	got, err := GetSitePackagesPath()
+	if err != nil {
+		t.Fatalf("GetSitePackagesPath() failed: %v", err)
+	}
+	if got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestExposeBinaries(t *testing.T) {
+	chdirTemp(t)
+	sitePackages := makeFakeVenv(t)
+	writeFile(t, filepath.Join(sitePackages, "mytool", "bin", "mytool"), "binary")
+
+	tool := config.Tool{
+		Version: "1.0.0",
+		Expose:  map[string]string{"mytool": "mytool/bin/mytool"},
+	}
+	created, err := exposeBinaries("mytool", tool)
+	if err != nil {
+		t.Fatalf("exposeBinaries() failed: %v", err)
+	}
+
+	want := filepath.Join(GetBinPath(), exeName("mytool"))
+	if len(created) != 1 || created[0] != want {
+		t.Fatalf("expected [%s], got %v", want, created)
+	}
+	if _, err := os.Stat(want); err != nil {
+		t.Errorf("expected exposed binary at %s: %v", want, err)
+	}
+}
+
+func TestExposeBinariesMissingSource(t *testing.T) {
+	chdirTemp(t)
+	makeFakeVenv(t)
+
+	tool := config.Tool{
+		Version: "1.0.0",
+		Expose:  map[string]string{"mytool": "mytool/bin/mytool"},
+	}
+	if _, err := exposeBinaries("mytool", tool); err == nil {
+		t.Error("expected error for missing exposed binary")
+	}
+}
+
+func TestSetupZig(t *testing.T) {
+	chdirTemp(t)
+	sitePackages := makeFakeVenv(t)
+	writeFile(t, filepath.Join(sitePackages, "ziglang", exeName("zig")), "binary")
+
+	created, err := setupZig("ziglang", config.Tool{Version: "0.13.0"})
+	if err != nil {
+		t.Fatalf("setupZig() failed: %v", err)
+	}
+	if len(created) != 3 {
+		t.Fatalf("expected zig link and two wrappers, got %v", created)
+	}
+
+	ccPath, _ := GetZigWrapperPaths()
+	target, err := ReadWrapperTarget(ccPath)
+	if err != nil {
+		t.Fatalf("ReadWrapperTarget() failed: %v", err)
+	}
+	if _, err := os.Stat(target); err != nil {
+		t.Errorf("expected wrapper to point at an existing zig, got %s", target)
+	}
+}
+
+func TestRunPostInstall(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell command")
+	}
+	chdirTemp(t)
+	makeFakeVenv(t)
+
+	tool := config.Tool{
+		Version:     "1.0.0",
+		PostInstall: config.PostInstall{Run: []string{`touch "$CPPENV_BIN/generated"`}},
+	}
+	created, err := runPostInstall("mytool", tool)
+	if err != nil {
+		t.Fatalf("runPostInstall() failed: %v", err)
+	}
+
+	want := filepath.Join(GetBinPath(), "generated")
+	if len(created) != 1 || created[0] != want {
+		t.Errorf("expected [%s], got %v", want, created)
+	}
+}
+
+func TestRunPostInstallFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell command")
+	}
+	chdirTemp(t)
+	makeFakeVenv(t)
+
+	tool := config.Tool{
+		Version:     "1.0.0",
+		PostInstall: config.PostInstall{Run: []string{"exit 3", `touch "$CPPENV_BIN/never"`}},
+	}
+	if _, err := runPostInstall("mytool", tool); err == nil {
+		t.Error("expected error from failing post-install command")
+	}
+	if _, err := os.Stat(filepath.Join(GetBinPath(), "never")); err == nil {
+		t.Error("expected commands after a failure not to run")
+	}
+}
+
+func TestSetupClangTools(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as a fake clang-tools")
+	}
+	chdirTemp(t)
+	makeFakeVenv(t)
+
+	// Fake installer that records its arguments and creates a binary
+	script := "#!/bin/sh\necho \"$@\" > \"$0.args\"\ntouch \"$(dirname \"$0\")/clang-format-17\"\n"
+	writeFile(t, filepath.Join(GetBinPath(), "clang-tools"), script)
+
+	tool := config.Tool{Version: "0.16.0", LLVM: "17", Binaries: []string{"clang-format"}}
+	created, err := setupClangTools("clang-tools", tool)
+	if err != nil {
+		t.Fatalf("setupClangTools() failed: %v", err)
+	}
+
+	args, err := os.ReadFile(filepath.Join(GetBinPath(), "clang-tools.args"))
+	if err != nil {
+		t.Fatalf("failed to read recorded arguments: %v", err)
+	}
+	want := "--install 17 --directory " + GetBinPath() + " --tool clang-format\n"
+	if string(args) != want {
+		t.Errorf("expected arguments %q, got %q", want, string(args))
+	}
+
+	found := false
+	for _, path := range created {
+		if filepath.Base(path) == "clang-format-17" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected clang-format-17 to be recorded as an artifact, got %v", created)
+	}
+}
@@ -1,14 +1,22 @@
 package environment
 
 import (
+	"context"
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
+	"syscall"
+	"time"
+
+	"github.com/michxymi/cppenv/internal/config"
 )
 
 //go:embed conan_provider.cmake
@@ -16,6 +24,10 @@ var conanProviderContent []byte
 
 const VenvDir = ".cppenv"
 
+// stopGracePeriod is how long a command has to exit after being signaled
+// before it is killed
+var stopGracePeriod = 5 * time.Second
+
 // GetCppenvDir returns the path to the .cppenv directory
 func GetCppenvDir() string {
 	cwd, _ := os.Getwd()
@@ -35,6 +47,11 @@ func GetBinPath() string {
 	return filepath.Join(GetVenvPath(), "bin")
 }
 
+// GetCachePath returns the path to the .cppenv/cache directory
+func GetCachePath() string {
+	return filepath.Join(GetCppenvDir(), "cache")
+}
+
 // GetPip returns the path to pip in the venv
 func GetPip() string {
 	if runtime.GOOS == "windows" {
@@ -59,6 +76,7 @@ func Create(pythonPath string) error {
 	}
 
 	cmd := exec.Command(pythonPath, "-m", "venv", venvPath)
+	cmd.Env = baseEnv()
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to create venv: %w", err)
 	}
@@ -67,136 +85,158 @@ func Create(pythonPath string) error {
 }
 
 // InstallTools installs the given requirements into the venv
-// tools is the map of tool names to versions from config
-func InstallTools(reqs []string, tools map[string]string) error {
+// tools is the map of tool names from config; after pip has installed the
+// packages, each tool's adapters run and their results are recorded in
+// the install state so they can be undone later
+func InstallTools(reqs []string, tools map[string]config.Tool) error {
 	pip := GetPip()
 
 	// Upgrade pip first
 	cmd := exec.Command(pip, "install", "--upgrade", "pip")
+	cmd.Env = baseEnv()
 	cmd.Run() // Ignore errors on pip upgrade
 
 	// Install all requirements
 	args := append([]string{"install"}, reqs...)
 	cmd = exec.Command(pip, args...)
+	cmd.Env = baseEnv()
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to install tools: %w", err)
 	}
 
-	// Create symlinks for tools that don't put binaries in bin/
-	createToolSymlinks()
+	state, err := loadState()
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(tools))
+	for name := range tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
-	// Install clang-tools binaries if clang-tools is in the config
-	if _, hasClangTools := tools["clang-tools"]; hasClangTools {
-		if err := installClangToolsBinaries(); err != nil {
-			return fmt.Errorf("failed to install clang-tools binaries: %w", err)
+	for _, name := range names {
+		tool := tools[name]
+		if prev, ok := state.Tools[name]; ok && prev.isCurrent(tool) {
+			continue
+		}
+		if err := setupTool(state, name, tool); err != nil {
+			state.save()
+			return err
 		}
 	}
 
-	return nil
+	return state.save()
 }
 
-// createToolSymlinks creates symlinks for tools that store binaries elsewhere
-func createToolSymlinks() {
-	binPath := GetBinPath()
-	venvPath := GetVenvPath()
+// UninstallRemovedTools uninstalls tools that were installed previously but
+// are no longer in the config, undoing their adapters
+// Returns the names of the tools that were removed
+func UninstallRemovedTools(tools map[string]config.Tool) ([]string, error) {
+	state, err := loadState()
+	if err != nil {
+		return nil, err
+	}
 
-	// Zig is stored in site-packages/ziglang/zig
-	zigSource := filepath.Join(venvPath, "lib", "python3.11", "site-packages", "ziglang", "zig")
-	zigTarget := filepath.Join(binPath, "zig")
-
-	// Check for python 3.x directories if 3.11 doesn't exist
-	if _, err := os.Stat(zigSource); os.IsNotExist(err) {
-		// Try to find the correct python version directory
-		libPath := filepath.Join(venvPath, "lib")
-		entries, _ := os.ReadDir(libPath)
-		for _, entry := range entries {
-			if entry.IsDir() && strings.HasPrefix(entry.Name(), "python") {
-				zigSource = filepath.Join(libPath, entry.Name(), "site-packages", "ziglang", "zig")
-				if _, err := os.Stat(zigSource); err == nil {
-					break
-				}
-			}
+	var removed []string
+	for name := range state.Tools {
+		if _, ok := tools[name]; !ok {
+			removed = append(removed, name)
 		}
 	}
+	sort.Strings(removed)
 
-	// Create zig symlink if source exists and target doesn't
-	if _, err := os.Stat(zigSource); err == nil {
-		if _, err := os.Stat(zigTarget); os.IsNotExist(err) {
-			os.Symlink(zigSource, zigTarget)
+	for _, name := range removed {
+		cmd := exec.Command(GetPip(), "uninstall", "-y", name)
+		cmd.Env = baseEnv()
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("failed to uninstall %s: %w", name, err)
+		}
+		if err := state.removeArtifacts(name); err != nil {
+			return nil, err
 		}
-		// Create zig-cc and zig-c++ wrapper scripts for CMake/Conan compatibility
-		// Place them in .cppenv directory, not in venv/bin
-		cppenvDir := GetCppenvDir()
-		os.MkdirAll(cppenvDir, 0755)
-		createZigWrapperScripts(cppenvDir, zigTarget)
+		delete(state.Tools, name)
 	}
-}
 
-// createZigWrapperScripts creates zig-cc and zig-c++ wrapper scripts
-// These are needed because CMake/Conan need to invoke "zig cc" and "zig c++"
-// but they expect a single executable path
-// targetDir is the directory where the wrappers should be created (.cppenv directory)
-func createZigWrapperScripts(targetDir, zigPath string) {
-	// Convert to forward slashes for shell scripts
-	zigPath = strings.ReplaceAll(zigPath, "\\", "/")
+	if len(removed) == 0 {
+		return nil, nil
+	}
+	return removed, state.save()
+}
 
-	if runtime.GOOS == "windows" {
-		// Create batch files on Windows
-		ccContent := fmt.Sprintf("@echo off\r\n\"%s\" cc %%*\r\n", zigPath)
-		cxxContent := fmt.Sprintf("@echo off\r\n\"%s\" c++ %%*\r\n", zigPath)
-		os.WriteFile(filepath.Join(targetDir, "zig-cc.bat"), []byte(ccContent), 0755)
-		os.WriteFile(filepath.Join(targetDir, "zig-c++.bat"), []byte(cxxContent), 0755)
-	} else {
-		// Create shell scripts on Unix
-		ccContent := fmt.Sprintf("#!/bin/sh\nexec \"%s\" cc \"$@\"\n", zigPath)
-		cxxContent := fmt.Sprintf("#!/bin/sh\nexec \"%s\" c++ \"$@\"\n", zigPath)
-		ccPath := filepath.Join(targetDir, "zig-cc")
-		cxxPath := filepath.Join(targetDir, "zig-c++")
-		os.WriteFile(ccPath, []byte(ccContent), 0755)
-		os.WriteFile(cxxPath, []byte(cxxContent), 0755)
+// RepairTool re-runs the adapters of an installed tool
+func RepairTool(name string) error {
+	if !Exists() {
+		return fmt.Errorf("environment not found, run 'cppenv install' first")
 	}
+	state, err := loadState()
+	if err != nil {
+		return err
+	}
+	tool := state.Tools[name].Tool
+	if err := setupTool(state, name, tool); err != nil {
+		return err
+	}
+	return state.save()
 }
 
-// installClangToolsBinaries runs 'clang-tools --install' to download clang binaries
-// Uses version 19 by default (latest stable LLVM release)
-func installClangToolsBinaries() error {
-	binPath := GetBinPath()
-	clangToolsPath := filepath.Join(binPath, "clang-tools")
+// GetZigWrapperPaths returns the paths of the zig-cc and zig-c++ wrappers
+func GetZigWrapperPaths() (string, string) {
+	cppenvDir := GetCppenvDir()
 	if runtime.GOOS == "windows" {
-		clangToolsPath = filepath.Join(binPath, "clang-tools.exe")
+		return filepath.Join(cppenvDir, "zig-cc.bat"), filepath.Join(cppenvDir, "zig-c++.bat")
 	}
+	return filepath.Join(cppenvDir, "zig-cc"), filepath.Join(cppenvDir, "zig-c++")
+}
 
-	// Check if clang-tools CLI exists
-	if _, err := os.Stat(clangToolsPath); os.IsNotExist(err) {
-		return fmt.Errorf("clang-tools CLI not found at %s", clangToolsPath)
+// ReadWrapperTarget returns the zig executable a wrapper script invokes
+func ReadWrapperTarget(wrapperPath string) (string, error) {
+	content, err := os.ReadFile(wrapperPath)
+	if err != nil {
+		return "", err
 	}
-
-	// Install clang binaries (version 19 is latest stable)
-	cmd := exec.Command(clangToolsPath, "--install", "19", "--directory", binPath)
-	cmd.Env = GetActivatedEnv()
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to run clang-tools --install: %w", err)
+	// Both the shell and batch wrappers quote the zig path
+	text := string(content)
+	start := strings.Index(text, "\"")
+	if start < 0 {
+		return "", fmt.Errorf("no zig path found in %s", wrapperPath)
+	}
+	end := strings.Index(text[start+1:], "\"")
+	if end < 0 {
+		return "", fmt.Errorf("no zig path found in %s", wrapperPath)
 	}
+	return filepath.FromSlash(text[start+1 : start+1+end]), nil
+}
 
+// setupTool undoes a tool's previous adapter results, runs its adapters
+// again and records what they created
+func setupTool(state *installState, name string, tool config.Tool) error {
+	if err := state.removeArtifacts(name); err != nil {
+		return err
+	}
+	artifacts, err := runAdapters(name, tool)
+	state.record(name, tool, artifacts)
+	if err != nil {
+		return fmt.Errorf("failed to set up %s: %w", name, err)
+	}
 	return nil
 }
 
-// GetActivatedEnv returns environment variables with PATH prepended
+// GetActivatedEnv returns environment variables with the project's [env]
+// applied and the venv bin directory prepended to PATH
 func GetActivatedEnv() []string {
-	binPath := GetBinPath()
-	pathSep := ":"
-	if runtime.GOOS == "windows" {
-		pathSep = ";"
-	}
+	return prependPath(baseEnv(), GetBinPath())
+}
 
-	env := os.Environ()
+// prependPath returns a copy of env with dir prepended to PATH
+func prependPath(env []string, dir string) []string {
 	newEnv := make([]string, 0, len(env))
 
 	pathSet := false
 	for _, e := range env {
 		if strings.HasPrefix(strings.ToUpper(e), "PATH=") {
 			parts := strings.SplitN(e, "=", 2)
-			newPath := binPath + pathSep + parts[1]
+			newPath := dir + string(os.PathListSeparator) + parts[1]
 			newEnv = append(newEnv, "PATH="+newPath)
 			pathSet = true
 		} else {
@@ -205,12 +245,39 @@ func GetActivatedEnv() []string {
 	}
 
 	if !pathSet {
-		newEnv = append(newEnv, "PATH="+binPath)
+		newEnv = append(newEnv, "PATH="+dir)
 	}
 
 	return newEnv
 }
 
+// LookupTool returns the path of a tool in the venv bin directory
+// The second return value is false if the tool is not installed
+func LookupTool(name string) (string, bool) {
+	path := resolveCommand(name)
+	if path == name {
+		return "", false
+	}
+	return path, true
+}
+
+// ReadVenvConfig parses the venv's pyvenv.cfg into a key/value map
+func ReadVenvConfig() (map[string]string, error) {
+	content, err := os.ReadFile(filepath.Join(GetVenvPath(), "pyvenv.cfg"))
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	for _, line := range strings.Split(string(content), "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return values, nil
+}
+
 // resolveCommand looks up a command in the venv bin directory first
 func resolveCommand(name string) string {
 	binPath := GetBinPath()
@@ -232,29 +299,254 @@ func resolveCommand(name string) string {
 	return name
 }
 
-// RunCommand runs a command with the activated environment
-func RunCommand(args []string) int {
-	if len(args) == 0 {
+// CommandExists reports whether a command can be found with the PATH in env,
+// or in cppenv's own PATH, which commands also fall back to
+func CommandExists(name string, env []string) bool {
+	if strings.ContainsAny(name, `/\`) {
+		_, err := os.Stat(name)
+		return err == nil
+	}
+	if LookPath(name, env) != name {
+		return true
+	}
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// LookPath searches the PATH in env for a command, since exec.Command only
+// searches the PATH of the current process
+// It returns name unchanged if the command is not found
+func LookPath(name string, env []string) string {
+	if strings.ContainsAny(name, `/\`) {
+		return name
+	}
+
+	var pathList string
+	for _, e := range env {
+		if strings.HasPrefix(strings.ToUpper(e), "PATH=") {
+			pathList = e[len("PATH="):]
+		}
+	}
+
+	exts := []string{""}
+	if runtime.GOOS == "windows" {
+		exts = []string{".exe", ".bat", ".cmd", ""}
+	}
+	for _, dir := range filepath.SplitList(pathList) {
+		for _, ext := range exts {
+			path := filepath.Join(dir, name+ext)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path
+			}
+		}
+	}
+
+	// Fall back to the original name (will be looked up in our own PATH)
+	return name
+}
+
+// Command is a process to run in the project environment
+type Command struct {
+	Args []string
+	Env  []string
+	// Dir is the working directory; the current directory if empty
+	Dir string
+	// Stdin, Stdout and Stderr default to the process's own
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Offline runs the command without network access; see CheckOffline
+	Offline bool
+
+	// Interrupted is set by Run when the command was stopped by SIGINT (Ctrl-C)
+	Interrupted bool
+}
+
+// Run runs the command and returns its exit code
+// The command is looked up in the environment's PATH
+func (c *Command) Run() int {
+	return c.RunContext(context.Background())
+}
+
+// RunContext runs the command until it exits or ctx is canceled
+// The command runs in its own process group, which gets the terminal if
+// cppenv has it. SIGINT, SIGTERM and SIGHUP sent to cppenv are forwarded to
+// the whole group, and canceling ctx sends it SIGTERM; if anything in the
+// group is still running stopGracePeriod after that, even once the command
+// itself exited, the group is killed. A command killed by signal N returns
+// 128+N, like in a shell
+func (c *Command) RunContext(ctx context.Context) int {
+	if len(c.Args) == 0 {
 		return 1
 	}
 
-	// Resolve the command to full path if it exists in venv
-	cmdPath := resolveCommand(args[0])
+	cmd := exec.Command(LookPath(c.Args[0], c.Env), c.Args[1:]...)
+	cmd.Env = c.Env
+	cmd.Dir = c.Dir
+	cmd.Stdin = c.Stdin
+	cmd.Stdout = c.Stdout
+	cmd.Stderr = c.Stderr
+	if cmd.Stdin == nil {
+		cmd.Stdin = os.Stdin
+	}
+	if cmd.Stdout == nil {
+		cmd.Stdout = os.Stdout
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+	group := newProcessGroup(cmd)
+	defer group.close()
+	if c.Offline {
+		if err := setOffline(cmd); err != nil {
+			fmt.Fprintf(cmd.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
 
-	cmd := exec.Command(cmdPath, args[1:]...)
-	cmd.Env = GetActivatedEnv()
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	// Listen before starting so that no signal is missed in between
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	defer signal.Stop(signals)
 
-	if err := cmd.Run(); err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return exitErr.ExitCode()
+	if err := group.start(); err != nil {
+		if c.Offline {
+			err = offlineStartError(err)
 		}
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintf(cmd.Stderr, "Error: %v\n", err)
 		return 1
 	}
-	return 0
+
+	exited := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		canceled := ctx.Done()
+		var kill <-chan time.Time
+		var poll *time.Ticker
+		var polled <-chan time.Time
+		defer func() {
+			if poll != nil {
+				poll.Stop()
+			}
+		}()
+		for {
+			select {
+			case <-canceled:
+				canceled = nil
+				group.signal(syscall.SIGTERM)
+			case sig := <-signals:
+				group.signal(sig)
+			case <-kill:
+				group.kill()
+				return
+			case <-exited:
+				if kill == nil {
+					return
+				}
+				// Processes that ignored the signal outlive the command,
+				// and are killed once the grace period is over
+				exited = nil
+				poll = time.NewTicker(50 * time.Millisecond)
+				polled = poll.C
+			case <-polled:
+				if !group.running() {
+					return
+				}
+			}
+			if kill == nil {
+				kill = time.After(stopGracePeriod)
+			}
+		}
+	}()
+
+	err := cmd.Wait()
+	close(exited)
+	<-stopped
+	if cmd.ProcessState != nil {
+		c.Interrupted = interrupted(cmd.ProcessState)
+		return exitCode(cmd.ProcessState)
+	}
+	fmt.Fprintf(cmd.Stderr, "Error: %v\n", err)
+	return 1
+}
+
+// RunCommand runs a command with the given environment
+func RunCommand(args []string, env []string) int {
+	return (&Command{Args: args, Env: env}).Run()
+}
+
+// MergeEnv returns a copy of env with the given variables set, replacing
+// existing values
+func MergeEnv(env []string, vars map[string]string) []string {
+	if len(vars) == 0 {
+		return env
+	}
+
+	merged := make([]string, 0, len(env)+len(vars))
+	for _, e := range env {
+		key, _, _ := strings.Cut(e, "=")
+		if _, ok := lookupVar(vars, key); !ok {
+			merged = append(merged, e)
+		}
+	}
+
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		merged = append(merged, key+"="+vars[key])
+	}
+	return merged
+}
+
+// DiffEnv returns the variables that differ between two environments, with
+// their values in to; variables missing from to have nil values
+func DiffEnv(from, to []string) map[string]*string {
+	before := envMap(from)
+	after := envMap(to)
+
+	diff := make(map[string]*string)
+	for key, value := range after {
+		if old, ok := lookupVar(before, key); !ok || old != value {
+			diff[key] = &value
+		}
+	}
+	for key := range before {
+		if _, ok := lookupVar(after, key); !ok {
+			diff[key] = nil
+		}
+	}
+	return diff
+}
+
+// envMap turns a list of KEY=value entries into a map; later entries win
+func envMap(env []string) map[string]string {
+	vars := make(map[string]string, len(env))
+	for _, e := range env {
+		if key, value, ok := strings.Cut(e, "="); ok && key != "" {
+			vars[key] = value
+		}
+	}
+	return vars
+}
+
+// lookupVar finds a variable by name, ignoring case on Windows
+func lookupVar(vars map[string]string, key string) (string, bool) {
+	if value, ok := vars[key]; ok {
+		return value, true
+	}
+	if runtime.GOOS == "windows" {
+		for k, value := range vars {
+			if strings.EqualFold(k, key) {
+				return value, true
+			}
+		}
+	}
+	return "", false
 }
 
 // CreateToolchainFile generates the CMake toolchain file for Zig
@@ -339,6 +631,41 @@ func AddToGitignore() (bool, error) {
 	return err == nil, err
 }
 
+// IsGitignored reports whether .cppenv/ is ignored by git
+// Uses 'git check-ignore' when inside a git repository and falls back to
+// reading .gitignore otherwise
+func IsGitignored() (bool, error) {
+	cwd, _ := os.Getwd()
+
+	if _, err := exec.LookPath("git"); err == nil {
+		if exec.Command("git", "-C", cwd, "rev-parse", "--git-dir").Run() == nil {
+			err := exec.Command("git", "-C", cwd, "check-ignore", "-q", VenvDir+"/").Run()
+			if err == nil {
+				return true, nil
+			}
+			if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+				return false, nil
+			}
+			return false, fmt.Errorf("git check-ignore failed: %w", err)
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(cwd, ".gitignore"))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == ".cppenv" || line == ".cppenv/" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // CreateCMakeUserPresets creates CMakeUserPresets.json in the .cppenv directory
 // Returns true if it was created, false if already exists
 func CreateCMakeUserPresets() (bool, error) {
@@ -121,3 +121,157 @@ func TestExists(t *testing.T) {
 		t.Error("expected Exists() to return true after creating venv directory")
 	}
 }
+
+func TestReadWrapperTarget(t *testing.T) {
+	dir := t.TempDir()
+
+	createZigWrapperScripts(dir, "/opt/zig/zig")
+
+	ccName := "zig-cc"
+	if runtime.GOOS == "windows" {
+		ccName = "zig-cc.bat"
+	}
+	target, err := ReadWrapperTarget(filepath.Join(dir, ccName))
+	if err != nil {
+		t.Fatalf("ReadWrapperTarget() failed: %v", err)
+	}
+	if target != filepath.FromSlash("/opt/zig/zig") {
+		t.Errorf("expected /opt/zig/zig, got %s", target)
+	}
+}
+
+func TestReadVenvConfig(t *testing.T) {
+	origWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	defer os.Chdir(origWd)
+
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+
+	if err := os.MkdirAll(GetVenvPath(), 0755); err != nil {
+		t.Fatalf("failed to create venv directory: %v", err)
+	}
+	content := "home = /opt/python/bin\ninclude-system-site-packages = false\nversion = 3.11.7\n"
+	if err := os.WriteFile(filepath.Join(GetVenvPath(), "pyvenv.cfg"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write pyvenv.cfg: %v", err)
+	}
+
+	cfg, err := ReadVenvConfig()
+	if err != nil {
+		t.Fatalf("ReadVenvConfig() failed: %v", err)
+	}
+	if cfg["home"] != "/opt/python/bin" {
+		t.Errorf("expected home '/opt/python/bin', got '%s'", cfg["home"])
+	}
+	if cfg["version"] != "3.11.7" {
+		t.Errorf("expected version '3.11.7', got '%s'", cfg["version"])
+	}
+}
+
+func TestIsGitignored(t *testing.T) {
+	origWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	defer os.Chdir(origWd)
+
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+
+	ignored, err := IsGitignored()
+	if err != nil {
+		t.Fatalf("IsGitignored() failed: %v", err)
+	}
+	if ignored {
+		t.Error("expected .cppenv not to be ignored without a .gitignore")
+	}
+
+	if _, err := AddToGitignore(); err != nil {
+		t.Fatalf("AddToGitignore() failed: %v", err)
+	}
+
+	ignored, err = IsGitignored()
+	if err != nil {
+		t.Fatalf("IsGitignored() failed: %v", err)
+	}
+	if !ignored {
+		t.Error("expected .cppenv to be ignored after AddToGitignore()")
+	}
+}
+
+func TestLookPath(t *testing.T) {
+	dir := t.TempDir()
+	name := "mytool"
+	if runtime.GOOS == "windows" {
+		name = "mytool.exe"
+	}
+	toolPath := filepath.Join(dir, name)
+	if err := os.WriteFile(toolPath, []byte("binary"), 0755); err != nil {
+		t.Fatalf("failed to write tool: %v", err)
+	}
+
+	env := []string{"PATH=" + dir}
+	if got := LookPath("mytool", env); got != toolPath {
+		t.Errorf("expected %s, got %s", toolPath, got)
+	}
+
+	// Unknown commands fall back to the bare name
+	if got := LookPath("missing-tool", env); got != "missing-tool" {
+		t.Errorf("expected bare name for missing tool, got %s", got)
+	}
+
+	if !CommandExists("mytool", env) || !CommandExists(toolPath, env) {
+		t.Error("expected mytool to exist")
+	}
+	if CommandExists("missing-tool", env) {
+		t.Error("expected missing-tool not to exist")
+	}
+}
+
+func TestMergeEnv(t *testing.T) {
+	env := []string{"PATH=/usr/bin", "LEVEL=1", "HOME=/home/user"}
+
+	merged := MergeEnv(env, map[string]string{"LEVEL": "8", "NEW": "value"})
+
+	want := map[string]bool{
+		"PATH=/usr/bin":   true,
+		"HOME=/home/user": true,
+		"LEVEL=8":         true,
+		"NEW=value":       true,
+	}
+	if len(merged) != len(want) {
+		t.Fatalf("expected %d variables, got %v", len(want), merged)
+	}
+	for _, e := range merged {
+		if !want[e] {
+			t.Errorf("unexpected variable %q", e)
+		}
+	}
+
+	// The original environment is not modified
+	if env[1] != "LEVEL=1" {
+		t.Errorf("expected original env to be unchanged, got %v", env)
+	}
+}
+
+func TestDiffEnv(t *testing.T) {
+	from := []string{"PATH=/usr/bin", "LEVEL=1", "HOME=/home/user", "GONE=x"}
+	to := []string{"PATH=/venv/bin:/usr/bin", "LEVEL=1", "HOME=/home/user", "NEW=value"}
+
+	diff := DiffEnv(from, to)
+	if len(diff) != 3 {
+		t.Fatalf("expected 3 changed variables, got %v", diff)
+	}
+	if *diff["PATH"] != "/venv/bin:/usr/bin" || *diff["NEW"] != "value" {
+		t.Errorf("expected changed and new values, got %v", diff)
+	}
+	if value, ok := diff["GONE"]; !ok || value != nil {
+		t.Errorf("expected removed variable with nil value, got %v", diff)
+	}
+}
@@ -0,0 +1,229 @@
+package environment
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/michxymi/cppenv/internal/config"
+)
+
+// ExposedDir is the directory inside .cppenv holding links to the binaries
+// that 'cppenv run' puts on PATH
+const ExposedDir = "bin"
+
+// GetExposedPath returns the path to the directory of exposed binaries
+func GetExposedPath() string {
+	return filepath.Join(GetCppenvDir(), ExposedDir)
+}
+
+// GetRunEnv returns the environment for commands started by 'cppenv run'
+// In tools isolation mode only the configured tools' binaries are on PATH,
+// otherwise the whole venv bin directory is; in hermetic mode inherited
+// variables outside the allowlist are dropped
+func GetRunEnv(cfg *config.Config) ([]string, error) {
+	if cfg == nil || cfg.Run.GetIsolation() == config.IsolationVenv {
+		return prependPath(runBaseEnv(), GetBinPath()), nil
+	}
+	if err := SyncExposedDir(cfg); err != nil {
+		return nil, err
+	}
+	return prependPath(runBaseEnv(), GetExposedPath()), nil
+}
+
+// LookupRunBinary returns the path of a binary that 'cppenv run' puts on PATH
+// from the project environment, and the environment to run it in
+// The last return value is false if the project has no such binary
+func LookupRunBinary(cfg *config.Config, name string) (string, []string, bool) {
+	dir := GetBinPath()
+	if cfg.Run.GetIsolation() != config.IsolationVenv {
+		dir = GetExposedPath()
+	}
+
+	path := LookPath(name, []string{"PATH=" + dir})
+	if path == name && dir == GetExposedPath() {
+		// The exposed directory is only updated by install and run
+		if SyncExposedDir(cfg) == nil {
+			path = LookPath(name, []string{"PATH=" + dir})
+		}
+	}
+	if path == name {
+		return "", nil, false
+	}
+	return path, prependPath(runBaseEnv(), dir), true
+}
+
+// GetRunBinaries returns the names of the binaries 'cppenv run' puts on PATH
+// from the project environment, sorted
+func GetRunBinaries(cfg *config.Config) []string {
+	var names []string
+	if cfg == nil || cfg.Run.GetIsolation() == config.IsolationVenv {
+		for name := range listDir(GetBinPath()) {
+			names = append(names, name)
+		}
+	} else {
+		binaries, _ := GetExposedBinaries(cfg)
+		for name := range binaries {
+			names = append(names, name)
+		}
+	}
+
+	for i, name := range names {
+		if runtime.GOOS == "windows" {
+			names[i] = strings.TrimSuffix(name, filepath.Ext(name))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SyncExposedDir updates the exposed directory to link exactly the binaries
+// returned by GetExposedBinaries
+func SyncExposedDir(cfg *config.Config) error {
+	binaries, err := GetExposedBinaries(cfg)
+	if err != nil {
+		return err
+	}
+
+	exposedPath := GetExposedPath()
+	if err := os.MkdirAll(exposedPath, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", exposedPath, err)
+	}
+
+	// Remove stale entries, keeping links that already point at the right binary
+	for name := range listDir(exposedPath) {
+		path := filepath.Join(exposedPath, name)
+		source, ok := binaries[name]
+		if ok && sameFile(path, source) {
+			delete(binaries, name)
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+
+	for name, source := range binaries {
+		if err := linkBinary(source, filepath.Join(exposedPath, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetExposedBinaries returns the binaries to expose, keyed by file name
+// These are the entry points pip installed for each configured tool, the
+// binaries their adapters added to bin/, and any extras listed in [run] expose
+func GetExposedBinaries(cfg *config.Config) (map[string]string, error) {
+	binPath := GetBinPath()
+	binaries := make(map[string]string)
+
+	state, err := loadState()
+	if err != nil {
+		return nil, err
+	}
+
+	for name := range cfg.Tools {
+		entryPoints, err := GetEntryPoints(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range entryPoints {
+			binaries[filepath.Base(path)] = path
+		}
+		for _, path := range state.Tools[name].artifactPaths() {
+			if filepath.Dir(path) == binPath {
+				binaries[filepath.Base(path)] = path
+			}
+		}
+	}
+
+	for _, extra := range cfg.Run.Expose {
+		path, ok := LookupTool(extra)
+		if !ok {
+			return nil, fmt.Errorf("run.expose: %s not found in %s", extra, binPath)
+		}
+		binaries[filepath.Base(path)] = path
+	}
+
+	// Skip binaries whose source has gone missing, e.g. after a failed install
+	for name, path := range binaries {
+		if _, err := os.Stat(path); err != nil {
+			delete(binaries, name)
+		}
+	}
+	return binaries, nil
+}
+
+var distNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// normalizeDistName normalizes a package name the way pip names .dist-info directories
+func normalizeDistName(name string) string {
+	return strings.ToLower(distNameSeparators.ReplaceAllString(name, "_"))
+}
+
+// GetEntryPoints returns the files a package installed into the venv bin directory
+// It reads the package's RECORD file from site-packages; a package that is not
+// installed has no entry points
+func GetEntryPoints(pkg string) ([]string, error) {
+	sitePackages, err := GetSitePackagesPath()
+	if err != nil {
+		return nil, nil
+	}
+
+	distInfo := ""
+	want := normalizeDistName(pkg)
+	entries, _ := os.ReadDir(sitePackages)
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".dist-info")
+		if !ok {
+			continue
+		}
+		// Directory names look like clang_tools-0.16.0.dist-info
+		distName, _, _ := strings.Cut(name, "-")
+		if normalizeDistName(distName) == want {
+			distInfo = filepath.Join(sitePackages, entry.Name())
+			break
+		}
+	}
+	if distInfo == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(filepath.Join(distInfo, "RECORD"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read RECORD for %s: %w", pkg, err)
+	}
+	defer f.Close()
+
+	binPath := GetBinPath()
+	var paths []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// Lines look like ../../../bin/cmake,sha256=...,1234
+		file, _, _ := strings.Cut(scanner.Text(), ",")
+		path := filepath.Join(sitePackages, filepath.FromSlash(file))
+		if filepath.Dir(path) == binPath {
+			paths = append(paths, path)
+		}
+	}
+	return paths, scanner.Err()
+}
+
+// sameFile reports whether two paths resolve to the same file
+func sameFile(a, b string) bool {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(infoA, infoB)
+}
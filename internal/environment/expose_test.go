@@ -0,0 +1,126 @@
+package environment
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/michxymi/cppenv/internal/config"
+)
+
+// installFakePackage creates a dist-info RECORD listing the given bin entries
+func installFakePackage(t *testing.T, sitePackages, distInfo string, binaries ...string) {
+	t.Helper()
+	binDir := "bin"
+	if runtime.GOOS == "windows" {
+		binDir = "Scripts"
+	}
+
+	var record strings.Builder
+	for _, name := range binaries {
+		writeFile(t, filepath.Join(GetBinPath(), name), "binary")
+		rel, err := filepath.Rel(sitePackages, filepath.Join(GetVenvPath(), binDir, name))
+		if err != nil {
+			t.Fatalf("failed to compute relative path: %v", err)
+		}
+		record.WriteString(filepath.ToSlash(rel) + ",sha256=abc,6\n")
+	}
+	record.WriteString(distInfo + "/METADATA,sha256=abc,10\n")
+	writeFile(t, filepath.Join(sitePackages, distInfo, "RECORD"), record.String())
+}
+
+func TestGetEntryPoints(t *testing.T) {
+	chdirTemp(t)
+	sitePackages := makeFakeVenv(t)
+	installFakePackage(t, sitePackages, "clang_tools-0.16.0.dist-info", "clang-tools")
+
+	paths, err := GetEntryPoints("clang-tools")
+	if err != nil {
+		t.Fatalf("GetEntryPoints() failed: %v", err)
+	}
+	want := filepath.Join(GetBinPath(), "clang-tools")
+	if len(paths) != 1 || paths[0] != want {
+		t.Errorf("expected [%s], got %v", want, paths)
+	}
+
+	paths, err = GetEntryPoints("not-installed")
+	if err != nil || len(paths) != 0 {
+		t.Errorf("expected no entry points for missing package, got %v (%v)", paths, err)
+	}
+}
+
+func TestSyncExposedDir(t *testing.T) {
+	chdirTemp(t)
+	sitePackages := makeFakeVenv(t)
+	installFakePackage(t, sitePackages, "cmake-3.28.1.dist-info", "cmake", "ctest")
+	installFakePackage(t, sitePackages, "charset_normalizer-3.3.2.dist-info", "normalizer")
+	writeFile(t, filepath.Join(GetBinPath(), "python"), "binary")
+
+	cfg := &config.Config{
+		Tools: map[string]config.Tool{"cmake": {Version: "3.28.1"}},
+		Run:   config.RunConfig{Expose: []string{"python"}},
+	}
+
+	// A stale entry from an earlier config must be removed
+	writeFile(t, filepath.Join(GetExposedPath(), "stale"), "binary")
+
+	if err := SyncExposedDir(cfg); err != nil {
+		t.Fatalf("SyncExposedDir() failed: %v", err)
+	}
+
+	exposed := listDir(GetExposedPath())
+	for _, name := range []string{"cmake", "ctest", "python"} {
+		if !exposed[name] {
+			t.Errorf("expected %s to be exposed, got %v", name, exposed)
+		}
+	}
+	for _, name := range []string{"normalizer", "stale"} {
+		if exposed[name] {
+			t.Errorf("expected %s not to be exposed", name)
+		}
+	}
+
+	// Syncing again is a no-op
+	if err := SyncExposedDir(cfg); err != nil {
+		t.Fatalf("second SyncExposedDir() failed: %v", err)
+	}
+}
+
+func TestSyncExposedDirMissingExtra(t *testing.T) {
+	chdirTemp(t)
+	makeFakeVenv(t)
+
+	cfg := &config.Config{Run: config.RunConfig{Expose: []string{"missing"}}}
+	if err := SyncExposedDir(cfg); err == nil {
+		t.Error("expected error for missing extra binary")
+	}
+}
+
+func TestGetRunEnv(t *testing.T) {
+	chdirTemp(t)
+	makeFakeVenv(t)
+
+	tests := []struct {
+		isolation string
+		want      string
+	}{
+		{config.IsolationTools, GetExposedPath()},
+		{config.IsolationVenv, GetBinPath()},
+	}
+
+	for _, tt := range tests {
+		cfg := &config.Config{Run: config.RunConfig{Isolation: tt.isolation}}
+		env, err := GetRunEnv(cfg)
+		if err != nil {
+			t.Fatalf("GetRunEnv() failed: %v", err)
+		}
+		for _, e := range env {
+			if strings.HasPrefix(e, "PATH=") {
+				if !strings.HasPrefix(strings.TrimPrefix(e, "PATH="), tt.want) {
+					t.Errorf("%s: expected PATH to start with %s, got %s", tt.isolation, tt.want, e)
+				}
+			}
+		}
+	}
+}
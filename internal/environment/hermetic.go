@@ -0,0 +1,139 @@
+package environment
+
+import (
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// hermeticAllowEnv are the variables kept in hermetic mode on every platform:
+// what shells, terminals, locales and network access need to work
+var hermeticAllowEnv = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TERM", "COLORTERM", "NO_COLOR",
+	"LANG", "LANGUAGE", "LC_*", "TZ", "TMPDIR", "SSH_AUTH_SOCK", "CPPENV_*",
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy",
+}
+
+// hermeticAllowEnvWindows are the variables Windows programs need to start
+var hermeticAllowEnvWindows = []string{
+	"SYSTEMROOT", "SYSTEMDRIVE", "WINDIR", "COMSPEC", "PATHEXT", "TEMP", "TMP",
+	"USERNAME", "USERPROFILE", "HOMEDRIVE", "HOMEPATH", "APPDATA", "LOCALAPPDATA",
+	"PROGRAMDATA", "PROGRAMFILES", "PROGRAMFILES(X86)", "COMMONPROGRAMFILES",
+	"NUMBER_OF_PROCESSORS", "PROCESSOR_ARCHITECTURE", "OS",
+}
+
+// influentialEnv are variables that compilers, build systems and package
+// managers read, which make builds differ between machines
+var influentialEnv = []string{
+	"CC", "CXX", "CPP", "LD", "AR", "AS", "NM", "RANLIB", "STRIP", "OBJCOPY",
+	"CFLAGS", "CXXFLAGS", "CPPFLAGS", "LDFLAGS", "LDLIBS", "LIBS", "ASMFLAGS",
+	"CPATH", "C_INCLUDE_PATH", "CPLUS_INCLUDE_PATH", "LIBRARY_PATH",
+	"LD_LIBRARY_PATH", "LD_PRELOAD", "DYLD_*", "SDKROOT", "DEVELOPER_DIR",
+	"MACOSX_DEPLOYMENT_TARGET", "PKG_CONFIG*", "CMAKE_*", "CTEST_*", "CONAN_*",
+	"NINJA_*", "ZIG_*", "CCACHE_*", "SCCACHE_*", "VCPKG_*", "MAKEFLAGS", "MFLAGS",
+	"PYTHON*", "PIP_*", "VIRTUAL_ENV", "CONDA_PREFIX",
+	"INCLUDE", "LIB", "LIBPATH", "CL", "_CL_", "LINK", "VCINSTALLDIR", "VCTOOLSINSTALLDIR",
+}
+
+// SetHermetic makes 'cppenv run' start commands from a minimal environment,
+// as [run] inherit-env = false does
+func SetHermetic() {
+	projectEnv.hermetic = true
+}
+
+// IsHermetic reports whether 'cppenv run' starts commands from a minimal environment
+func IsHermetic() bool {
+	return projectEnv.hermetic
+}
+
+// runBaseEnv returns the environment 'cppenv run' starts from: the process
+// environment, reduced to the allowlist in hermetic mode, with the project's
+// variables applied
+func runBaseEnv() []string {
+	env := os.Environ()
+	if projectEnv.hermetic {
+		env = hermeticEnv(env, projectEnv.allow)
+	}
+	return applyProjectEnv(env)
+}
+
+// hermeticEnv returns the variables of env that are on the built-in
+// allowlist or match one of the allow patterns
+func hermeticEnv(env []string, allow []string) []string {
+	kept := make([]string, 0, len(env))
+	for _, e := range env {
+		key, _, _ := strings.Cut(e, "=")
+		if hermeticAllowed(key, allow) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// hermeticAllowed reports whether hermetic mode keeps a variable
+func hermeticAllowed(key string, allow []string) bool {
+	if matchEnvPattern(key, hermeticAllowEnv) || matchEnvPattern(key, allow) {
+		return true
+	}
+	return runtime.GOOS == "windows" && matchEnvPattern(key, hermeticAllowEnvWindows)
+}
+
+// matchEnvPattern reports whether a variable name matches a name in
+// patterns, or a prefix ending in *; names are case-insensitive on Windows
+func matchEnvPattern(key string, patterns []string) bool {
+	for _, pattern := range patterns {
+		name := pattern
+		prefix, isPrefix := strings.CutSuffix(pattern, "*")
+		if runtime.GOOS == "windows" {
+			key, name, prefix = strings.ToUpper(key), strings.ToUpper(name), strings.ToUpper(prefix)
+		}
+		if key == name || (isPrefix && strings.HasPrefix(key, prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// InheritedVar is an inherited environment variable that can change how
+// the project builds
+type InheritedVar struct {
+	Name  string
+	Value string
+	// Kept is false if hermetic mode drops the variable
+	Kept bool
+	// Overridden is set if [env] or an env file sets the variable
+	Overridden bool
+}
+
+// ExplainEnv returns the inherited variables that compilers, build systems
+// and package managers read, sorted by name
+func ExplainEnv() []InheritedVar {
+	var vars []InheritedVar
+	for _, e := range os.Environ() {
+		key, value, _ := strings.Cut(e, "=")
+		if key == "" || !matchEnvPattern(key, influentialEnv) {
+			continue
+		}
+		_, inFiles := lookupVar(projectEnv.files, key)
+		vars = append(vars, InheritedVar{
+			Name:       key,
+			Value:      value,
+			Kept:       !projectEnv.hermetic || hermeticAllowed(key, projectEnv.allow),
+			Overridden: replacedByEnv(key) || inFiles,
+		})
+	}
+	sort.Slice(vars, func(i, j int) bool { return vars[i].Name < vars[j].Name })
+	return vars
+}
+
+// replacedByEnv reports whether the [env] section replaces the inherited
+// value of a variable, ignoring case on Windows
+func replacedByEnv(key string) bool {
+	for name, v := range projectEnv.vars {
+		if v.Value != nil && (name == key || (runtime.GOOS == "windows" && strings.EqualFold(name, key))) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package environment
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/michxymi/cppenv/internal/config"
+)
+
+func TestHermeticEnv(t *testing.T) {
+	env := []string{
+		"PATH=/usr/bin",
+		"HOME=/home/me",
+		"LC_ALL=C",
+		"CC=gcc",
+		"CFLAGS=-O0",
+		"CMAKE_GENERATOR=Ninja",
+		"CONAN_HOME=/conan",
+		"CONANX=1",
+	}
+
+	got := strings.Join(hermeticEnv(env, []string{"CONAN_*", "CC"}), " ")
+	expected := "PATH=/usr/bin HOME=/home/me LC_ALL=C CC=gcc CONAN_HOME=/conan"
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestExplainEnv(t *testing.T) {
+	defer func() { projectEnv.vars, projectEnv.hermetic, projectEnv.allow = nil, false, nil }()
+
+	t.Setenv("CC", "gcc")
+	t.Setenv("CXX", "g++")
+	t.Setenv("CONAN_HOME", "/conan")
+	t.Setenv("UNRELATED_SETTING", "1")
+
+	clang := "clang++"
+	if err := SetProjectEnv(&config.Config{
+		Env: config.EnvConfig{Vars: map[string]config.EnvVar{"CXX": {Value: &clang}}},
+		Run: config.RunConfig{AllowEnv: []string{"CONAN_*"}},
+	}); err != nil {
+		t.Fatalf("SetProjectEnv() failed: %v", err)
+	}
+	SetHermetic()
+
+	vars := make(map[string]InheritedVar)
+	for _, v := range ExplainEnv() {
+		vars[v.Name] = v
+	}
+	if _, ok := vars["UNRELATED_SETTING"]; ok {
+		t.Error("expected unrelated variables to be left out")
+	}
+	if v := vars["CC"]; v.Value != "gcc" || v.Kept || v.Overridden {
+		t.Errorf("expected CC to be dropped, got %+v", v)
+	}
+	if v := vars["CXX"]; !v.Overridden {
+		t.Errorf("expected CXX to be overridden by [env], got %+v", v)
+	}
+	if v := vars["CONAN_HOME"]; !v.Kept {
+		t.Errorf("expected CONAN_HOME to be allowed, got %+v", v)
+	}
+
+	env := runBaseEnv()
+	if _, ok := getEnv(env, "CC"); ok {
+		t.Error("expected CC to be dropped from the run environment")
+	}
+	if value, _ := getEnv(env, "CXX"); value != "clang++" {
+		t.Errorf("expected CXX from [env], got %q", value)
+	}
+	if _, ok := getEnv(env, "PATH"); !ok {
+		t.Error("expected PATH to be kept")
+	}
+}
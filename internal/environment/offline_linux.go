@@ -0,0 +1,196 @@
+//go:build linux
+
+package environment
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// offlineHelperArg is the first argument of cppenv re-executing itself inside
+// a new network namespace, where it brings up loopback before starting the
+// actual command
+const offlineHelperArg = "__offline-exec"
+
+// offlineEnv is set for the processes in the namespace to the namespace's
+// inode number, see IsOffline
+const offlineEnv = "CPPENV_OFFLINE"
+
+// offlineHint explains the usual reasons creating namespaces fails
+const offlineHint = "unprivileged user namespaces may be disabled; check the sysctls " +
+	"user.max_user_namespaces and kernel.apparmor_restrict_unprivileged_userns"
+
+// CheckOffline reports whether commands can be run without network access
+// It starts a process in a new user and network namespace
+func CheckOffline() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the cppenv executable: %w", err)
+	}
+	cmd := exec.Command(exe, offlineHelperArg)
+	setNamespaces(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if len(out) > 0 {
+			return fmt.Errorf("failed to create a network namespace: %s", strings.TrimSpace(string(out)))
+		}
+		return fmt.Errorf("failed to create a network namespace (%s): %w", offlineHint, err)
+	}
+	return nil
+}
+
+// IsOffline reports whether cppenv runs in a network namespace it created
+// for --offline
+// As the environment can be set by anyone, it fails unless cppenv is in the
+// namespace named by it and that namespace only has loopback interfaces
+func IsOffline() (bool, error) {
+	value, ok := os.LookupEnv(offlineEnv)
+	if !ok {
+		return false, nil
+	}
+	ino, err := netNamespace()
+	if err != nil {
+		return false, fmt.Errorf("failed to check the network namespace: %w", err)
+	}
+	if value != strconv.FormatUint(ino, 10) {
+		return false, fmt.Errorf("%s is set, but cppenv is not in the network namespace it created for --offline; unset it to run offline", offlineEnv)
+	}
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return false, fmt.Errorf("failed to list network interfaces: %w", err)
+	}
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagLoopback == 0 {
+			return false, fmt.Errorf("%s is set, but the network interface %s is reachable; unset it to run offline", offlineEnv, iface.Name)
+		}
+	}
+	return true, nil
+}
+
+// netNamespace returns the inode number of the current network namespace
+func netNamespace() (uint64, error) {
+	info, err := os.Stat("/proc/self/ns/net")
+	if err != nil {
+		return 0, err
+	}
+	return info.Sys().(*syscall.Stat_t).Ino, nil
+}
+
+// RunOffline runs cppenv again with args in a new network namespace and
+// returns its exit code
+// Everything it starts shares that namespace, so a server a script starts in
+// the background can still be reached over loopback
+func RunOffline(args []string) int {
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to find the cppenv executable: %v\n", err)
+		return 1
+	}
+	cmd := &Command{Args: append([]string{exe}, args...), Env: os.Environ(), Offline: true}
+	return cmd.Run()
+}
+
+// setOffline makes cmd run in a new user and network namespace that only has
+// a loopback interface, through cppenv's offline helper
+// The user and group keep their IDs inside the namespace; the helper gets
+// CAP_NET_ADMIN there to bring up loopback and drops it before exec
+func setOffline(cmd *exec.Cmd) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the cppenv executable: %w", err)
+	}
+	cmd.Args = append([]string{exe, offlineHelperArg, cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = exe
+	// A command that is not on cppenv's own PATH is looked up by the helper
+	cmd.Err = nil
+	setNamespaces(cmd)
+	return nil
+}
+
+// setNamespaces starts cmd in a new user and network namespace
+func setNamespaces(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	attr := cmd.SysProcAttr
+	attr.Cloneflags |= syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET
+	attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}}
+	attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}}
+	attr.AmbientCaps = []uintptr{unix.CAP_NET_ADMIN}
+}
+
+// offlineStartError explains a failure to start a command in a new namespace
+func offlineStartError(err error) error {
+	if errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EINVAL) {
+		return fmt.Errorf("failed to create a network namespace (%s): %w", offlineHint, err)
+	}
+	return err
+}
+
+// RunOfflineHelper handles cppenv being re-executed by setOffline
+// It returns if args are not the helper's, and otherwise brings up loopback
+// and replaces the process with the command in args, or exits if it cannot
+func RunOfflineHelper(args []string) {
+	if len(args) < 2 || args[1] != offlineHelperArg {
+		return
+	}
+	if err := loopbackUp(); err != nil {
+		fmt.Fprintf(os.Stderr, "cppenv: failed to bring up loopback: %v\n", err)
+		os.Exit(1)
+	}
+	if len(args) == 2 {
+		os.Exit(0)
+	}
+
+	path := args[2]
+	if !strings.Contains(path, "/") {
+		found, err := exec.LookPath(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cppenv: %s: command not found\n", path)
+			os.Exit(127)
+		}
+		path = found
+	}
+
+	ino, err := netNamespace()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cppenv: failed to check the network namespace: %v\n", err)
+		os.Exit(1)
+	}
+	env := append(os.Environ(), offlineEnv+"="+strconv.FormatUint(ino, 10))
+
+	// Capabilities belong to threads; drop them on the thread that execs
+	runtime.LockOSThread()
+	unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0)
+	err = syscall.Exec(path, args[2:], env)
+	fmt.Fprintf(os.Stderr, "cppenv: %s: %v\n", args[2], err)
+	os.Exit(126)
+}
+
+// loopbackUp brings up the loopback interface of the current network namespace
+func loopbackUp() error {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+
+	ifr, err := unix.NewIfreq("lo")
+	if err != nil {
+		return err
+	}
+	if err := unix.IoctlIfreq(fd, unix.SIOCGIFFLAGS, ifr); err != nil {
+		return err
+	}
+	ifr.SetUint16(ifr.Uint16() | unix.IFF_UP)
+	return unix.IoctlIfreq(fd, unix.SIOCSIFFLAGS, ifr)
+}
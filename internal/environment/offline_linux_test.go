@@ -0,0 +1,83 @@
+//go:build linux
+
+package environment
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestMain lets the test binary act as the offline helper, like cppenv's main
+func TestMain(m *testing.M) {
+	RunOfflineHelper(os.Args)
+	os.Exit(m.Run())
+}
+
+func TestRunOffline(t *testing.T) {
+	if err := CheckOffline(); err != nil {
+		t.Skipf("network namespaces not available: %v", err)
+	}
+
+	var stdout bytes.Buffer
+	cmd := &Command{
+		Args:    []string{"sh", "-c", "cat /proc/net/dev; echo offline=$CPPENV_OFFLINE; stat -L -c ns=%i /proc/self/ns/net; exit 3"},
+		Env:     os.Environ(),
+		Stdout:  &stdout,
+		Offline: true,
+	}
+	if code := cmd.Run(); code != 3 {
+		t.Errorf("expected exit code 3, got %d", code)
+	}
+
+	var interfaces []string
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if name, _, ok := strings.Cut(line, ":"); ok && !strings.Contains(name, "|") {
+			interfaces = append(interfaces, strings.TrimSpace(name))
+		}
+	}
+	if len(interfaces) != 1 || interfaces[0] != "lo" {
+		t.Errorf("expected only a loopback interface, got %v", interfaces)
+	}
+	var offline, ns string
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if value, ok := strings.CutPrefix(line, "offline="); ok {
+			offline = value
+		} else if value, ok := strings.CutPrefix(line, "ns="); ok {
+			ns = value
+		}
+	}
+	if offline == "" || offline != ns {
+		t.Errorf("expected %s to be the namespace's inode %q, got %q", offlineEnv, ns, offline)
+	}
+
+	var stderr bytes.Buffer
+	cmd = &Command{Args: []string{"cppenv-missing-command"}, Env: os.Environ(), Stderr: &stderr, Offline: true}
+	if code := cmd.Run(); code != 127 {
+		t.Errorf("expected exit code 127 for a missing command, got %d: %s", code, stderr.String())
+	}
+}
+
+func TestIsOfflineForged(t *testing.T) {
+	t.Setenv(offlineEnv, "1")
+	if offline, err := IsOffline(); err == nil || offline {
+		t.Errorf("expected a forged %s to fail, got %v, %v", offlineEnv, offline, err)
+	}
+
+	ino, err := netNamespace()
+	if err != nil {
+		t.Fatalf("netNamespace() failed: %v", err)
+	}
+	t.Setenv(offlineEnv, strconv.FormatUint(ino, 10))
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		t.Fatalf("failed to list network interfaces: %v", err)
+	}
+	offline, err := IsOffline()
+	if len(interfaces) > 1 && (err == nil || offline) {
+		t.Errorf("expected %s to fail outside an offline namespace, got %v, %v", offlineEnv, offline, err)
+	}
+}
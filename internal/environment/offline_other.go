@@ -0,0 +1,38 @@
+//go:build !linux
+
+package environment
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// CheckOffline reports whether commands can be run without network access,
+// which needs Linux network namespaces
+func CheckOffline() error {
+	return fmt.Errorf("--offline needs Linux network namespaces, which %s does not have", runtime.GOOS)
+}
+
+// IsOffline is always false, see CheckOffline
+func IsOffline() (bool, error) {
+	return false, nil
+}
+
+// RunOffline reports that --offline is not supported and returns 1
+func RunOffline(args []string) int {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", CheckOffline())
+	return 1
+}
+
+func setOffline(cmd *exec.Cmd) error {
+	return CheckOffline()
+}
+
+func offlineStartError(err error) error {
+	return err
+}
+
+// RunOfflineHelper is only used on Linux
+func RunOfflineHelper(args []string) {}
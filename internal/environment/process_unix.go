@@ -0,0 +1,97 @@
+//go:build unix
+
+package environment
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// forwardedSignals are passed on from cppenv to the commands it runs
+var forwardedSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP}
+
+// processGroup is a command started in a process group of its own, so that
+// it can be stopped along with everything it starts
+type processGroup struct {
+	cmd             *exec.Cmd
+	restoreTerminal func()
+}
+
+// newProcessGroup sets up the command to start in a new process group
+// If its stdin is the terminal cppenv runs in the foreground of, the group
+// becomes the terminal's foreground group so that it gets Ctrl-C and can
+// read input
+func newProcessGroup(cmd *exec.Cmd) *processGroup {
+	g := &processGroup{cmd: cmd, restoreTerminal: func() {}}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	tty, ok := cmd.Stdin.(*os.File)
+	if !ok || !isForeground(tty) {
+		return g
+	}
+	cmd.SysProcAttr.Foreground = true
+	cmd.SysProcAttr.Ctty = int(tty.Fd())
+
+	g.restoreTerminal = func() {
+		// Taking the terminal back from the background sends SIGTTOU,
+		// which would stop cppenv
+		signal.Ignore(syscall.SIGTTOU)
+		defer signal.Reset(syscall.SIGTTOU)
+		unix.IoctlSetPointerInt(int(tty.Fd()), unix.TIOCSPGRP, unix.Getpgrp())
+	}
+	return g
+}
+
+// start starts the command
+func (g *processGroup) start() error {
+	return g.cmd.Start()
+}
+
+// close gives the terminal back to cppenv
+func (g *processGroup) close() {
+	g.restoreTerminal()
+}
+
+// isForeground reports whether f is a terminal and cppenv's process group is
+// its foreground group
+func isForeground(f *os.File) bool {
+	pgrp, err := unix.IoctlGetInt(int(f.Fd()), unix.TIOCGPGRP)
+	return err == nil && pgrp == unix.Getpgrp()
+}
+
+// signal sends a signal to the command and everything it started
+func (g *processGroup) signal(sig os.Signal) {
+	if s, ok := sig.(syscall.Signal); ok {
+		syscall.Kill(-g.cmd.Process.Pid, s)
+	}
+}
+
+// kill kills the command and everything it started
+func (g *processGroup) kill() {
+	syscall.Kill(-g.cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// running reports whether anything the command started is still running
+// after the command itself exited
+func (g *processGroup) running() bool {
+	return syscall.Kill(-g.cmd.Process.Pid, 0) == nil
+}
+
+// exitCode returns the exit code of a process, or 128+N if it was killed
+// by signal N
+func exitCode(state *os.ProcessState) int {
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return state.ExitCode()
+}
+
+// interrupted reports whether a process was killed by SIGINT
+func interrupted(state *os.ProcessState) bool {
+	status, ok := state.Sys().(syscall.WaitStatus)
+	return ok && status.Signaled() && status.Signal() == syscall.SIGINT
+}
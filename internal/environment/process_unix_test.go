@@ -0,0 +1,141 @@
+//go:build unix
+
+package environment
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRunContextStopsProcessGroup(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	// The background sleep is a grandchild that must be stopped as well
+	cmd := &Command{
+		Args: []string{"sh", "-c", "sleep 30 & echo $! > " + pidFile + "; wait"},
+		Env:  os.Environ(),
+	}
+
+	start := time.Now()
+	code := cmd.RunContext(ctx)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected the command to stop when canceled, took %v", elapsed)
+	}
+	if code == 0 {
+		t.Error("expected a nonzero exit code for a canceled command")
+	}
+
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatalf("failed to read pid file: %v", err)
+	}
+	var pid int
+	if _, err := fmt.Sscan(string(data), &pid); err != nil {
+		t.Fatalf("invalid pid %q", data)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if processRunning(pid) {
+		syscall.Kill(pid, syscall.SIGKILL)
+		t.Error("expected the grandchild process to be stopped")
+	}
+}
+
+// processRunning reports whether a process exists and is not a zombie
+// waiting to be reaped
+func processRunning(pid int) bool {
+	if _, err := os.Stat("/proc"); err == nil {
+		stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if err != nil {
+			return false
+		}
+		// The state follows the parenthesized command name
+		fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+		return len(fields) > 0 && fields[0] != "Z"
+	}
+	return syscall.Kill(pid, 0) == nil
+}
+
+func TestRunContextKillsProcessGroupAfterExit(t *testing.T) {
+	grace := stopGracePeriod
+	stopGracePeriod = 300 * time.Millisecond
+	t.Cleanup(func() { stopGracePeriod = grace })
+
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	// The shell exits on SIGTERM, but the background sleep ignores it
+	cmd := &Command{
+		Args: []string{"sh", "-c", "(trap '' TERM; exec sleep 30) & echo $! > " + pidFile + "; wait"},
+		Env:  os.Environ(),
+	}
+	cmd.RunContext(ctx)
+
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatalf("failed to read pid file: %v", err)
+	}
+	var pid int
+	if _, err := fmt.Sscan(string(data), &pid); err != nil {
+		t.Fatalf("invalid pid %q", data)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if processRunning(pid) {
+		syscall.Kill(pid, syscall.SIGKILL)
+		t.Error("expected the grandchild that ignored SIGTERM to be killed")
+	}
+}
+
+func TestRunSignalExitCode(t *testing.T) {
+	cmd := &Command{Args: []string{"sh", "-c", "kill -TERM $$"}, Env: os.Environ()}
+	if code := cmd.Run(); code != 128+int(syscall.SIGTERM) {
+		t.Errorf("expected exit code %d, got %d", 128+int(syscall.SIGTERM), code)
+	}
+
+	cmd = &Command{Args: []string{"sh", "-c", "kill -INT $$"}, Env: os.Environ()}
+	cmd.Run()
+	if !cmd.Interrupted {
+		t.Error("expected Interrupted to be set for a command killed by SIGINT")
+	}
+}
+
+func TestRunForwardsSignals(t *testing.T) {
+	ready := filepath.Join(t.TempDir(), "ready")
+	cmd := &Command{
+		Args: []string{"sh", "-c", "touch " + ready + "; sleep 30 & wait"},
+		Env:  os.Environ(),
+	}
+
+	done := make(chan int)
+	go func() { done <- cmd.Run() }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(ready); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("command did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// cppenv itself gets the signal; Run passes it on to the command
+	syscall.Kill(os.Getpid(), syscall.SIGHUP)
+	select {
+	case code := <-done:
+		if code != 128+int(syscall.SIGHUP) {
+			t.Errorf("expected exit code %d, got %d", 128+int(syscall.SIGHUP), code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the signal to stop the command")
+	}
+}
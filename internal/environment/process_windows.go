@@ -0,0 +1,131 @@
+//go:build windows
+
+package environment
+
+import (
+	"os"
+	"os/exec"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// forwardedSignals are caught while a command runs so that cppenv waits for
+// it; Ctrl-C already reaches every process attached to the console
+var forwardedSignals = []os.Signal{os.Interrupt}
+
+// processGroup is a command whose process tree is kept in a job object, so
+// that it can be stopped along with everything it starts
+// The command stays in cppenv's console process group, since Ctrl-C is
+// disabled in new groups
+type processGroup struct {
+	cmd *exec.Cmd
+	job windows.Handle
+}
+
+// jobAccountingInformation is JOBOBJECT_BASIC_ACCOUNTING_INFORMATION
+type jobAccountingInformation struct {
+	TotalUserTime             int64
+	TotalKernelTime           int64
+	ThisPeriodTotalUserTime   int64
+	ThisPeriodTotalKernelTime int64
+	TotalPageFaultCount       uint32
+	TotalProcesses            uint32
+	ActiveProcesses           uint32
+	TotalTerminatedProcesses  uint32
+}
+
+// newProcessGroup sets up the command to run in a process group
+func newProcessGroup(cmd *exec.Cmd) *processGroup {
+	return &processGroup{cmd: cmd}
+}
+
+// start starts the command and puts it in a job object that kills whatever
+// is left of its process tree once cppenv closes it or exits
+// Processes the command starts before it is added to the job are not in it;
+// if no job can be created, only the command itself can be stopped
+func (g *processGroup) start() error {
+	if err := g.cmd.Start(); err != nil {
+		return err
+	}
+	job, err := newJob(uint32(g.cmd.Process.Pid))
+	if err == nil {
+		g.job = job
+	}
+	return nil
+}
+
+// newJob creates a job object that kills its processes when closed and adds
+// the process with the given ID to it
+func newJob(pid uint32) (windows.Handle, error) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	info := windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION{}
+	info.BasicLimitInformation.LimitFlags = windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE
+	if _, err := windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+		windows.CloseHandle(job)
+		return 0, err
+	}
+	process, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, pid)
+	if err != nil {
+		windows.CloseHandle(job)
+		return 0, err
+	}
+	defer windows.CloseHandle(process)
+	if err := windows.AssignProcessToJobObject(job, process); err != nil {
+		windows.CloseHandle(job)
+		return 0, err
+	}
+	return job, nil
+}
+
+// close closes the job, killing anything the command left running
+func (g *processGroup) close() {
+	if g.job != 0 {
+		windows.CloseHandle(g.job)
+	}
+}
+
+// signal stops the command on anything but an interrupt, which the command
+// has received from the console itself
+// Windows cannot send other processes signals, so this kills its tree
+func (g *processGroup) signal(sig os.Signal) {
+	if sig != os.Interrupt {
+		g.kill()
+	}
+}
+
+// kill kills the command and everything it started
+func (g *processGroup) kill() {
+	if g.job == 0 {
+		g.cmd.Process.Kill()
+		return
+	}
+	windows.TerminateJobObject(g.job, 1)
+}
+
+// running reports whether anything the command started is still running
+// after the command itself exited
+func (g *processGroup) running() bool {
+	if g.job == 0 {
+		return false
+	}
+	var info jobAccountingInformation
+	err := windows.QueryInformationJobObject(g.job, windows.JobObjectBasicAccountingInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info)), nil)
+	return err == nil && info.ActiveProcesses > 0
+}
+
+// exitCode returns the exit code of a process
+func exitCode(state *os.ProcessState) int {
+	return state.ExitCode()
+}
+
+// interrupted reports whether a process was stopped by Ctrl-C
+func interrupted(state *os.ProcessState) bool {
+	// STATUS_CONTROL_C_EXIT
+	return uint32(state.ExitCode()) == 0xC000013A
+}
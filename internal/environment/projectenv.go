@@ -0,0 +1,155 @@
+package environment
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/michxymi/cppenv/internal/config"
+)
+
+// projectEnv holds the variables from the project's [env] section and
+// env-files, applied to every command cppenv starts, and the [run] settings
+// for hermetic mode
+var projectEnv struct {
+	vars     map[string]config.EnvVar
+	files    map[string]string
+	hermetic bool
+	allow    []string
+}
+
+// SetProjectEnv loads the [env] section and env-files of a project so that
+// they are applied to the commands cppenv starts
+// Env files are read relative to the project directory; missing files are skipped
+func SetProjectEnv(cfg *config.Config) error {
+	files := make(map[string]string)
+	for _, path := range cfg.EnvFiles {
+		vars, err := ReadEnvFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for key, value := range vars {
+			files[key] = value
+		}
+	}
+	projectEnv.vars = cfg.Env.ForPlatform(runtime.GOOS)
+	projectEnv.files = files
+	projectEnv.hermetic = cfg.Run.IsHermetic()
+	projectEnv.allow = cfg.Run.AllowEnv
+	return nil
+}
+
+// baseEnv returns the current process environment with the project's
+// variables applied
+func baseEnv() []string {
+	return applyProjectEnv(os.Environ())
+}
+
+// applyProjectEnv applies the [env] section to env, then the env-files,
+// which take precedence
+func applyProjectEnv(env []string) []string {
+	vars := make(map[string]string, len(projectEnv.vars))
+	for key, v := range projectEnv.vars {
+		value, _ := getEnv(env, key)
+		if v.Value != nil {
+			value = *v.Value
+		}
+		parts := append([]string(nil), v.Prepend...)
+		if value != "" {
+			parts = append(parts, value)
+		}
+		parts = append(parts, v.Append...)
+		vars[key] = strings.Join(parts, string(os.PathListSeparator))
+	}
+	return MergeEnv(MergeEnv(env, vars), projectEnv.files)
+}
+
+// getEnv returns the value of a variable in env, ignoring case on Windows
+func getEnv(env []string, key string) (string, bool) {
+	for i := len(env) - 1; i >= 0; i-- {
+		k, value, _ := strings.Cut(env[i], "=")
+		if k == key || (runtime.GOOS == "windows" && strings.EqualFold(k, key)) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// ReadEnvFile parses a dotenv file of KEY=value lines
+// Blank lines and lines starting with # are ignored, an "export " prefix is
+// allowed, and values may be single-quoted (literal) or double-quoted
+// (with \n, \t, \" and \\ escapes)
+func ReadEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("%s:%d: expected KEY=value", path, lineNo)
+		}
+		if !config.IsEnvName(key) {
+			return nil, fmt.Errorf("%s:%d: invalid variable name %q", path, lineNo, key)
+		}
+		value, err := parseEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		vars[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return vars, nil
+}
+
+// parseEnvValue unquotes a dotenv value, dropping a trailing comment from
+// unquoted values
+func parseEnvValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "'"):
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated quoted value")
+		}
+		return value[1 : end+1], nil
+	case strings.HasPrefix(value, `"`):
+		end := 1
+		for ; end < len(value) && value[end] != '"'; end++ {
+			if value[end] == '\\' {
+				end++
+			}
+		}
+		if end >= len(value) {
+			return "", fmt.Errorf("unterminated quoted value")
+		}
+		unquoted, err := strconv.Unquote(value[:end+1])
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted value: %w", err)
+		}
+		return unquoted, nil
+	default:
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		return value, nil
+	}
+}
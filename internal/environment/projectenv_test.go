@@ -0,0 +1,101 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/michxymi/cppenv/internal/config"
+)
+
+func TestReadEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := `# comment
+LEVEL=8
+export CONAN_HOME = /opt/conan  # trailing comment
+
+SINGLE='a # b $x'
+DOUBLE="line\nnext \"quoted\""
+EMPTY=
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write env file: %v", err)
+	}
+
+	vars, err := ReadEnvFile(path)
+	if err != nil {
+		t.Fatalf("ReadEnvFile() failed: %v", err)
+	}
+	expected := map[string]string{
+		"LEVEL":      "8",
+		"CONAN_HOME": "/opt/conan",
+		"SINGLE":     "a # b $x",
+		"DOUBLE":     "line\nnext \"quoted\"",
+		"EMPTY":      "",
+	}
+	if len(vars) != len(expected) {
+		t.Errorf("expected %d variables, got %v", len(expected), vars)
+	}
+	for key, value := range expected {
+		if vars[key] != value {
+			t.Errorf("expected %s=%q, got %q", key, value, vars[key])
+		}
+	}
+
+	for _, bad := range []string{"LEVEL\n", "A B=1\n", "A=\"open\n", "=1\n", "X;echo=1\n", "1A=1\n"} {
+		if err := os.WriteFile(path, []byte("OK=1\n"+bad), 0644); err != nil {
+			t.Fatalf("failed to write env file: %v", err)
+		}
+		if _, err := ReadEnvFile(path); err == nil || !strings.Contains(err.Error(), ".env:2:") {
+			t.Errorf("expected error with line number for %q, got %v", bad, err)
+		}
+	}
+}
+
+func TestApplyProjectEnv(t *testing.T) {
+	origWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	defer os.Chdir(origWd)
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+	defer func() { projectEnv.vars, projectEnv.files = nil, nil }()
+
+	if err := os.WriteFile(".env", []byte("LEVEL=from-file\n"), 0644); err != nil {
+		t.Fatalf("failed to write env file: %v", err)
+	}
+	level, home := "8", "/opt/conan"
+	cfg := &config.Config{
+		EnvFiles: []string{".env", "missing.env"},
+		Env: config.EnvConfig{Vars: map[string]config.EnvVar{
+			"LEVEL":      {Value: &level},
+			"CONAN_HOME": {Value: &home},
+			"PATH":       {Prepend: []string{"/tools"}, Append: []string{"/opt/bin"}},
+			"NEW_PATH":   {Append: []string{"/a"}},
+		}},
+	}
+	if err := SetProjectEnv(cfg); err != nil {
+		t.Fatalf("SetProjectEnv() failed: %v", err)
+	}
+
+	sep := string(os.PathListSeparator)
+	env := applyProjectEnv([]string{"PATH=/usr/bin", "HOME=/home/me", "LEVEL=1"})
+	expected := map[string]string{
+		"PATH":       "/tools" + sep + "/usr/bin" + sep + "/opt/bin",
+		"HOME":       "/home/me",
+		"LEVEL":      "from-file",
+		"CONAN_HOME": "/opt/conan",
+		"NEW_PATH":   "/a",
+	}
+	for key, value := range expected {
+		if got, _ := getEnv(env, key); got != value {
+			t.Errorf("expected %s=%q, got %q", key, value, got)
+		}
+	}
+	if len(env) != len(expected) {
+		t.Errorf("expected %d variables, got %v", len(expected), env)
+	}
+}
@@ -0,0 +1,130 @@
+package environment
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/michxymi/cppenv/internal/config"
+)
+
+const stateFile = "state.json"
+
+// installState records what cppenv installed into the project environment
+type installState struct {
+	Tools map[string]installedTool `json:"tools"`
+}
+
+// installedTool is a tool as it was installed, and the files its adapters created
+// Artifact paths are relative to the .cppenv directory
+type installedTool struct {
+	Tool      config.Tool `json:"tool"`
+	Artifacts []string    `json:"artifacts,omitempty"`
+}
+
+// GetInstalledTool returns a tool as it was last installed into the environment
+func GetInstalledTool(name string) (config.Tool, bool) {
+	state, err := loadState()
+	if err != nil {
+		return config.Tool{}, false
+	}
+	entry, ok := state.Tools[name]
+	return entry.Tool, ok
+}
+
+func getStatePath() string {
+	return filepath.Join(GetCppenvDir(), stateFile)
+}
+
+// loadState reads the install state, returning an empty state if none exists
+func loadState() (*installState, error) {
+	state := &installState{Tools: make(map[string]installedTool)}
+
+	content, err := os.ReadFile(getStatePath())
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", stateFile, err)
+	}
+	if err := json.Unmarshal(content, state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", stateFile, err)
+	}
+	if state.Tools == nil {
+		state.Tools = make(map[string]installedTool)
+	}
+	return state, nil
+}
+
+func (s *installState) save() error {
+	if err := os.MkdirAll(GetCppenvDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create .cppenv directory: %w", err)
+	}
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if err := os.WriteFile(getStatePath(), content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", stateFile, err)
+	}
+	return nil
+}
+
+// record stores a tool and the absolute paths its adapters created
+func (s *installState) record(name string, tool config.Tool, artifacts []string) {
+	cppenvDir := GetCppenvDir()
+	entry := installedTool{Tool: tool}
+	for _, path := range artifacts {
+		if rel, err := filepath.Rel(cppenvDir, path); err == nil {
+			path = filepath.ToSlash(rel)
+		}
+		entry.Artifacts = append(entry.Artifacts, path)
+	}
+	s.Tools[name] = entry
+}
+
+// removeArtifacts deletes the files a tool's adapters created
+func (s *installState) removeArtifacts(name string) error {
+	entry, ok := s.Tools[name]
+	if !ok {
+		return nil
+	}
+	for _, path := range entry.artifactPaths() {
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+	entry.Artifacts = nil
+	s.Tools[name] = entry
+	return nil
+}
+
+// artifactPaths returns the absolute paths of the tool's artifacts
+func (t installedTool) artifactPaths() []string {
+	cppenvDir := GetCppenvDir()
+	paths := make([]string, len(t.Artifacts))
+	for i, path := range t.Artifacts {
+		path = filepath.FromSlash(path)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(cppenvDir, path)
+		}
+		paths[i] = path
+	}
+	return paths
+}
+
+// isCurrent reports whether the installed tool matches the configured one and
+// all of its artifacts are still present
+func (t installedTool) isCurrent(tool config.Tool) bool {
+	if !reflect.DeepEqual(t.Tool, tool) {
+		return false
+	}
+	for _, path := range t.artifactPaths() {
+		if _, err := os.Lstat(path); err != nil {
+			return false
+		}
+	}
+	return true
+}
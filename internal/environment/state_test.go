@@ -0,0 +1,76 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/michxymi/cppenv/internal/config"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	chdirTemp(t)
+
+	state, err := loadState()
+	if err != nil {
+		t.Fatalf("loadState() failed: %v", err)
+	}
+	if len(state.Tools) != 0 {
+		t.Fatalf("expected empty state, got %v", state.Tools)
+	}
+
+	artifact := filepath.Join(GetCppenvDir(), "zig-cc")
+	writeFile(t, artifact, "wrapper")
+
+	tool := config.Tool{Version: "0.13.0"}
+	state.record("ziglang", tool, []string{artifact})
+	if err := state.save(); err != nil {
+		t.Fatalf("save() failed: %v", err)
+	}
+
+	loaded, err := loadState()
+	if err != nil {
+		t.Fatalf("loadState() failed: %v", err)
+	}
+	entry, ok := loaded.Tools["ziglang"]
+	if !ok {
+		t.Fatal("expected ziglang in loaded state")
+	}
+	// Artifacts are stored relative to .cppenv so the project can be moved
+	if len(entry.Artifacts) != 1 || entry.Artifacts[0] != "zig-cc" {
+		t.Errorf("expected relative artifact 'zig-cc', got %v", entry.Artifacts)
+	}
+	if !entry.isCurrent(tool) {
+		t.Error("expected recorded tool to be current")
+	}
+	if entry.isCurrent(config.Tool{Version: "0.14.0"}) {
+		t.Error("expected a version change to make the tool out of date")
+	}
+}
+
+func TestStateRemoveArtifacts(t *testing.T) {
+	chdirTemp(t)
+
+	artifact := filepath.Join(GetCppenvDir(), "zig-cc")
+	writeFile(t, artifact, "wrapper")
+
+	state, err := loadState()
+	if err != nil {
+		t.Fatalf("loadState() failed: %v", err)
+	}
+	tool := config.Tool{Version: "0.13.0"}
+	state.record("ziglang", tool, []string{artifact})
+
+	if err := state.removeArtifacts("ziglang"); err != nil {
+		t.Fatalf("removeArtifacts() failed: %v", err)
+	}
+	if _, err := os.Stat(artifact); !os.IsNotExist(err) {
+		t.Error("expected artifact to be removed")
+	}
+
+	// A tool whose artifacts went missing must be set up again
+	state.record("ziglang", tool, []string{artifact})
+	if state.Tools["ziglang"].isCurrent(tool) {
+		t.Error("expected tool with missing artifacts not to be current")
+	}
+}
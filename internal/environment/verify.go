@@ -0,0 +1,151 @@
+package environment
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/michxymi/cppenv/internal/config"
+)
+
+// verifyTimeout bounds how long a single smoke check may run
+const verifyTimeout = 30 * time.Second
+
+// smokeCheck describes how to check that an installed tool actually runs
+type smokeCheck struct {
+	binary string
+	args   []string
+	// version returns the version the binary should report
+	version func(tool config.Tool) string
+}
+
+func pinnedVersion(tool config.Tool) string {
+	return tool.Version
+}
+
+// smokeChecks maps package names to the command used to verify them
+var smokeChecks = map[string]smokeCheck{
+	"cmake":   {binary: "cmake", args: []string{"--version"}, version: pinnedVersion},
+	"ziglang": {binary: "zig", args: []string{"version"}, version: pinnedVersion},
+	"ninja":   {binary: "ninja", args: []string{"--version"}, version: pinnedVersion},
+	"conan":   {binary: "conan", args: []string{"--version"}, version: pinnedVersion},
+	// The clang-tools package version is the installer's, so check the LLVM version instead
+	"clang-tools": {binary: "clang-format", args: []string{"--version"}, version: config.Tool.GetLLVMVersion},
+}
+
+// getSmokeCheck returns the smoke check for a tool, if there is one
+func getSmokeCheck(name string, tool config.Tool) (smokeCheck, bool) {
+	check, ok := smokeChecks[name]
+	if !ok {
+		return check, false
+	}
+	// Only some clang binaries may have been installed
+	if name == "clang-tools" && len(tool.Binaries) > 0 {
+		check.binary = tool.Binaries[0]
+	}
+	return check, true
+}
+
+var versionPattern = regexp.MustCompile(`\d+(\.\d+)+`)
+
+// Verification is the result of smoke-checking a single tool
+type Verification struct {
+	Tool    string
+	Command string
+	Version string // version reported by the tool, if any
+	Err     error
+}
+
+// VerifyTools runs each configured tool and checks it reports the expected version
+// Tools without a known smoke check are skipped
+func VerifyTools(tools map[string]config.Tool) []Verification {
+	names := make([]string, 0, len(tools))
+	for name := range tools {
+		if _, ok := smokeChecks[name]; ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	results := make([]Verification, 0, len(names))
+	for _, name := range names {
+		check, _ := getSmokeCheck(name, tools[name])
+		results = append(results, verifyTool(name, check.version(tools[name]), check))
+	}
+	return results
+}
+
+func verifyTool(name, expected string, check smokeCheck) Verification {
+	result := Verification{
+		Tool:    name,
+		Command: strings.Join(append([]string{check.binary}, check.args...), " "),
+	}
+
+	path, ok := LookupTool(check.binary)
+	if !ok {
+		result.Err = fmt.Errorf("%s not found in %s", check.binary, GetBinPath())
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), verifyTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path, check.args...)
+	cmd.Env = GetActivatedEnv()
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		result.Err = fmt.Errorf("'%s' timed out after %s", result.Command, verifyTimeout)
+		return result
+	}
+	if err != nil {
+		result.Err = fmt.Errorf("'%s' failed: %w%s", result.Command, err, formatOutput(out))
+		return result
+	}
+
+	result.Version = parseVersion(string(out))
+	if result.Version == "" {
+		result.Err = fmt.Errorf("could not find a version in the output of '%s'%s", result.Command, formatOutput(out))
+		return result
+	}
+	if !versionMatches(expected, result.Version) {
+		result.Err = fmt.Errorf("reports version %s, expected %s", result.Version, expected)
+	}
+	return result
+}
+
+// parseVersion returns the first dotted version number in the output
+func parseVersion(output string) string {
+	return versionPattern.FindString(output)
+}
+
+// versionMatches compares a pinned package version with the version a tool reports
+// Wheel versions often carry an extra component (ninja 1.11.1.1 reports 1.11.1),
+// so either version may be a dotted prefix of the other; a bare major version
+// such as an LLVM version of "17" matches any 17.x release
+func versionMatches(pinned, reported string) bool {
+	if major, err := strconv.Atoi(pinned); err == nil {
+		return strings.HasPrefix(reported, strconv.Itoa(major)+".")
+	}
+	pinned = parseVersion(pinned)
+	if pinned == "" || reported == "" {
+		return false
+	}
+	if pinned == reported {
+		return true
+	}
+	return strings.HasPrefix(pinned, reported+".") || strings.HasPrefix(reported, pinned+".")
+}
+
+// formatOutput indents command output for inclusion in an error message
+func formatOutput(out []byte) string {
+	text := strings.TrimSpace(string(out))
+	if text == "" {
+		return ""
+	}
+	return "\n      " + strings.ReplaceAll(text, "\n", "\n      ")
+}
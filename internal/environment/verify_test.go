@@ -0,0 +1,157 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/michxymi/cppenv/internal/config"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"cmake version 3.29.2\n\nCMake suite maintained and supported by Kitware", "3.29.2"},
+		{"0.13.0\n", "0.13.0"},
+		{"1.11.1.git.kitware.jobserver-1\n", "1.11.1"},
+		{"Conan version 2.3.0\n", "2.3.0"},
+		{"no version here", ""},
+	}
+
+	for _, tt := range tests {
+		if got := parseVersion(tt.output); got != tt.want {
+			t.Errorf("parseVersion(%q) = %q, expected %q", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestVersionMatches(t *testing.T) {
+	tests := []struct {
+		pinned   string
+		reported string
+		want     bool
+	}{
+		{"3.29.2", "3.29.2", true},
+		{"1.11.1.1", "1.11.1", true},
+		{"0.11.0.post1", "0.11.0", true},
+		{"3.29.2", "3.29.3", false},
+		{"3.29.2", "3.2", false},
+		{"1.11.1", "1.11.10", false},
+		{"", "1.0", false},
+		{"17", "17.0.6", true},
+		{"17", "18.1.3", false},
+	}
+
+	for _, tt := range tests {
+		if got := versionMatches(tt.pinned, tt.reported); got != tt.want {
+			t.Errorf("versionMatches(%q, %q) = %v, expected %v", tt.pinned, tt.reported, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyToolsMissingBinary(t *testing.T) {
+	origWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	defer os.Chdir(origWd)
+
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+
+	results := VerifyTools(map[string]config.Tool{
+		"cmake":   {Version: "3.29.2"},
+		"unknown": {Version: "1.0.0"},
+	})
+
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result (unknown tools skipped), got %d", len(results))
+	}
+	if results[0].Tool != "cmake" {
+		t.Errorf("expected result for cmake, got %s", results[0].Tool)
+	}
+	if results[0].Err == nil {
+		t.Error("expected error for missing cmake binary")
+	}
+}
+
+func TestVerifyToolsVersionMismatch(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as a fake tool")
+	}
+
+	origWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	defer os.Chdir(origWd)
+
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+
+	if err := os.MkdirAll(GetBinPath(), 0755); err != nil {
+		t.Fatalf("failed to create bin directory: %v", err)
+	}
+	script := "#!/bin/sh\necho 'cmake version 3.28.1'\n"
+	if err := os.WriteFile(filepath.Join(GetBinPath(), "cmake"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake cmake: %v", err)
+	}
+
+	results := VerifyTools(map[string]config.Tool{"cmake": {Version: "3.28.1"}})
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("expected cmake 3.28.1 to verify, got %+v", results)
+	}
+	if results[0].Version != "3.28.1" {
+		t.Errorf("expected reported version 3.28.1, got %s", results[0].Version)
+	}
+
+	results = VerifyTools(map[string]config.Tool{"cmake": {Version: "3.29.2"}})
+	if len(results) != 1 || results[0].Err == nil {
+		t.Fatalf("expected version mismatch error, got %+v", results)
+	}
+}
+
+func TestVerifyToolsClangTools(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as a fake tool")
+	}
+
+	origWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	defer os.Chdir(origWd)
+
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+
+	if err := os.MkdirAll(GetBinPath(), 0755); err != nil {
+		t.Fatalf("failed to create bin directory: %v", err)
+	}
+	script := "#!/bin/sh\necho 'clang-tidy version 17.0.6'\n"
+	if err := os.WriteFile(filepath.Join(GetBinPath(), "clang-tidy"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake clang-tidy: %v", err)
+	}
+
+	// The package version is ignored in favour of the LLVM version
+	tool := config.Tool{Version: "0.16.0", LLVM: "17", Binaries: []string{"clang-tidy"}}
+	results := VerifyTools(map[string]config.Tool{"clang-tools": tool})
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("expected clang-tools to verify against LLVM 17, got %+v", results)
+	}
+
+	tool.LLVM = "19"
+	results = VerifyTools(map[string]config.Tool{"clang-tools": tool})
+	if len(results) != 1 || results[0].Err == nil {
+		t.Fatalf("expected LLVM version mismatch error, got %+v", results)
+	}
+}
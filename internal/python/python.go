@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -19,8 +20,11 @@ const (
 	BaseURL       = "https://github.com/indygreg/python-build-standalone/releases/download"
 )
 
-// GetCppenvHome returns the global cppenv directory (~/.cppenv)
+// GetCppenvHome returns the global cppenv directory: $CPPENV_HOME, or ~/.cppenv
 func GetCppenvHome() string {
+	if dir := os.Getenv("CPPENV_HOME"); dir != "" {
+		return dir
+	}
 	home, _ := os.UserHomeDir()
 	return filepath.Join(home, ".cppenv")
 }
@@ -44,6 +48,25 @@ func IsInstalled() bool {
 	return err == nil
 }
 
+// InstalledVersion runs the managed Python and returns the version it reports
+func InstalledVersion() (string, error) {
+	out, err := exec.Command(GetPythonPath(), "--version").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to run Python: %w", err)
+	}
+	// Output looks like "Python 3.11.7"
+	fields := strings.Fields(string(out))
+	if len(fields) != 2 || fields[0] != "Python" {
+		return "", fmt.Errorf("unexpected output from python --version: %q", strings.TrimSpace(string(out)))
+	}
+	return fields[1], nil
+}
+
+// Remove deletes the managed Python installation
+func Remove() error {
+	return os.RemoveAll(GetPythonHome())
+}
+
 // getDownloadURL returns the download URL for the current platform
 func getDownloadURL() (string, error) {
 	var target string
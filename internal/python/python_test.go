@@ -9,6 +9,7 @@ import (
 )
 
 func TestGetPythonHome(t *testing.T) {
+	t.Setenv("CPPENV_HOME", "")
 	home := GetPythonHome()
 
 	if home == "" {
@@ -29,6 +30,13 @@ func TestGetPythonHome(t *testing.T) {
 	if !strings.HasSuffix(home, filepath.Join(".cppenv", "python")) {
 		t.Errorf("expected path to end with .cppenv/python, got %s", home)
 	}
+
+	// CPPENV_HOME moves the global directory
+	dir := t.TempDir()
+	t.Setenv("CPPENV_HOME", dir)
+	if home := GetPythonHome(); home != filepath.Join(dir, "python") {
+		t.Errorf("expected path under CPPENV_HOME, got %s", home)
+	}
 }
 
 func TestGetPythonPath(t *testing.T) {
@@ -0,0 +1,79 @@
+package scripts
+
+import (
+	"regexp"
+	"strings"
+)
+
+// argsPlaceholders mark where extra command-line arguments go in a script
+// "$@" is listed before $@ so its quotes are replaced along with it
+var argsPlaceholders = []string{"{args}", `"$@"`, "$@"}
+
+// WithArgs adds extra command-line arguments to a script
+// If the script contains an {args} or $@ placeholder the quoted arguments
+// replace it, otherwise they are appended to the end
+func WithArgs(script string, args []string) string {
+	return withArgs(script, args, defaultQuote())
+}
+
+// QuoteArgs quotes arguments for the shell scripts run with on this platform
+func QuoteArgs(args []string) string {
+	return quoteArgs(args, defaultQuote())
+}
+
+func withArgs(script string, args []string, quote func(string) string) string {
+	quoted := quoteArgs(args, quote)
+
+	for _, placeholder := range argsPlaceholders {
+		if strings.Contains(script, placeholder) {
+			for _, p := range argsPlaceholders {
+				script = strings.ReplaceAll(script, p, quoted)
+			}
+			return script
+		}
+	}
+
+	if quoted == "" {
+		return script
+	}
+	return script + " " + quoted
+}
+
+func quoteArgs(args []string, quote func(string) string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+var safePOSIX = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// quotePOSIX quotes an argument for sh, leaving plain words untouched
+func quotePOSIX(arg string) string {
+	if safePOSIX.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+var safeWindows = regexp.MustCompile(`^[A-Za-z0-9_@+=:,./\\-]+$`)
+
+// quoteWindows quotes an argument for cmd /c, leaving plain words untouched
+// cmd expands %VAR% even inside quotes, so each % is written outside the
+// quotes and escaped as ^%, which no variable name can match
+func quoteWindows(arg string) string {
+	if safeWindows.MatchString(arg) {
+		return arg
+	}
+	arg = strings.ReplaceAll(arg, `"`, `""`)
+	return `"` + strings.ReplaceAll(arg, "%", `"^%"`) + `"`
+}
+
+// quotePowerShell quotes an argument for PowerShell, leaving plain words untouched
+func quotePowerShell(arg string) string {
+	if safePOSIX.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", "''") + "'"
+}
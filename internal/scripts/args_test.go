@@ -0,0 +1,65 @@
+package scripts
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestWithArgs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("expectations use POSIX quoting")
+	}
+
+	tests := []struct {
+		script string
+		args   []string
+		want   string
+	}{
+		{"ctest --test-dir build", nil, "ctest --test-dir build"},
+		{"ctest --test-dir build", []string{"-R", "parser"}, "ctest --test-dir build -R parser"},
+		{"ctest --test-dir build", []string{"-R", "a b"}, "ctest --test-dir build -R 'a b'"},
+		{"clang-format {args} src/main.cpp", []string{"-i"}, "clang-format -i src/main.cpp"},
+		{`echo "$@" done`, []string{"it's"}, `echo 'it'\''s' done`},
+		{"echo $@ done", []string{"x"}, "echo x done"},
+		{"echo {args} done", nil, "echo  done"},
+	}
+
+	for _, tt := range tests {
+		if got := WithArgs(tt.script, tt.args); got != tt.want {
+			t.Errorf("WithArgs(%q, %q) = %q, expected %q", tt.script, tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestQuotePOSIX(t *testing.T) {
+	tests := map[string]string{
+		"plain":     "plain",
+		"--flag=1":  "--flag=1",
+		"two words": "'two words'",
+		"it's":      `'it'\''s'`,
+		"*.cpp":     "'*.cpp'",
+		"":          "''",
+	}
+	for arg, want := range tests {
+		if got := quotePOSIX(arg); got != want {
+			t.Errorf("quotePOSIX(%q) = %q, expected %q", arg, got, want)
+		}
+	}
+}
+
+func TestQuoteWindows(t *testing.T) {
+	tests := map[string]string{
+		"plain":       "plain",
+		`C:\build`:    `C:\build`,
+		"two words":   `"two words"`,
+		`say "hello"`: `"say ""hello"""`,
+		"":            `""`,
+		"%PATH%":      `""^%"PATH"^%""`,
+		"100% done":   `"100"^%" done"`,
+	}
+	for arg, want := range tests {
+		if got := quoteWindows(arg); got != want {
+			t.Errorf("quoteWindows(%q) = %q, expected %q", arg, got, want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"mvdan.cc/sh/v3/interp"
+)
+
+// builtinFunc runs a built-in command; its error is printed as the
+// command's error message
+type builtinFunc func(dir string, flags map[rune]bool, args []string) error
+
+// builtin is a command the built-in shell implements itself
+type builtin struct {
+	run   builtinFunc
+	flags string
+}
+
+var builtins = map[string]builtin{
+	"rm":    {run: builtinRm, flags: "rRf"},
+	"mkdir": {run: builtinMkdir, flags: "p"},
+	"cp":    {run: builtinCp, flags: "rR"},
+}
+
+// builtinCommands runs the built-in commands instead of looking them up on PATH
+func builtinCommands(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(ctx context.Context, args []string) error {
+		cmd, ok := builtins[args[0]]
+		if !ok {
+			return next(ctx, args)
+		}
+
+		hc := interp.HandlerCtx(ctx)
+		flags, operands, err := parseFlags(args[1:], cmd.flags)
+		if err == nil {
+			err = cmd.run(hc.Dir, flags, operands)
+		}
+		if err != nil {
+			fmt.Fprintf(hc.Stderr, "%s: %v\n", args[0], err)
+			return interp.NewExitStatus(1)
+		}
+		return nil
+	}
+}
+
+// parseFlags splits single-letter flags such as -rf from the other arguments
+func parseFlags(args []string, allowed string) (map[rune]bool, []string, error) {
+	flags := make(map[rune]bool)
+	for i, arg := range args {
+		if arg == "--" {
+			return flags, args[i+1:], nil
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			return flags, args[i:], nil
+		}
+		for _, flag := range arg[1:] {
+			if !strings.ContainsRune(allowed, flag) {
+				return nil, nil, fmt.Errorf("unsupported option -%c", flag)
+			}
+			flags[flag] = true
+		}
+	}
+	return flags, nil, nil
+}
+
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// builtinRm removes files, and directories with -r
+// With -f missing files are ignored
+func builtinRm(dir string, flags map[rune]bool, args []string) error {
+	recursive := flags['r'] || flags['R']
+	for _, arg := range args {
+		path := resolvePath(dir, arg)
+		info, err := os.Lstat(path)
+		if err != nil {
+			if os.IsNotExist(err) && flags['f'] {
+				continue
+			}
+			return err
+		}
+		if info.IsDir() && !recursive {
+			return fmt.Errorf("%s: is a directory", arg)
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+	if len(args) == 0 && !flags['f'] {
+		return fmt.Errorf("missing operand")
+	}
+	return nil
+}
+
+// builtinMkdir creates directories, and their parents with -p
+func builtinMkdir(dir string, flags map[rune]bool, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing operand")
+	}
+	for _, arg := range args {
+		path := resolvePath(dir, arg)
+		var err error
+		if flags['p'] {
+			err = os.MkdirAll(path, 0755)
+		} else {
+			err = os.Mkdir(path, 0755)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// builtinCp copies files, and directories with -r
+// The last argument is the destination; it must be a directory when there
+// are several sources
+func builtinCp(dir string, flags map[rune]bool, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("missing destination")
+	}
+	sources, dest := args[:len(args)-1], resolvePath(dir, args[len(args)-1])
+
+	destInfo, err := os.Stat(dest)
+	destIsDir := err == nil && destInfo.IsDir()
+	if len(sources) > 1 && !destIsDir {
+		return fmt.Errorf("%s: not a directory", args[len(args)-1])
+	}
+
+	for _, source := range sources {
+		src := resolvePath(dir, source)
+		info, err := os.Stat(src)
+		if err != nil {
+			return err
+		}
+		target := dest
+		if destIsDir {
+			target = filepath.Join(dest, filepath.Base(src))
+		}
+		if info.IsDir() {
+			if !flags['r'] && !flags['R'] {
+				return fmt.Errorf("%s: is a directory", source)
+			}
+			err = copyDir(src, target)
+		} else {
+			err = copyFile(src, target, info.Mode())
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyDir(src, dest string) error {
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm())
+		}
+		return copyFile(path, target, info.Mode())
+	})
+}
+
+func copyFile(src, dest string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,70 @@
+package scripts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseFlags(t *testing.T) {
+	flags, args, err := parseFlags([]string{"-rf", "-R", "build", "-x"}, "rRf")
+	if err != nil {
+		t.Fatalf("parseFlags() failed: %v", err)
+	}
+	if !flags['r'] || !flags['f'] || !flags['R'] {
+		t.Errorf("expected r, f and R flags, got %v", flags)
+	}
+	if len(args) != 2 || args[0] != "build" || args[1] != "-x" {
+		t.Errorf("expected flags to end at the first operand, got %v", args)
+	}
+
+	if _, args, _ := parseFlags([]string{"--", "-rf"}, "rf"); len(args) != 1 || args[0] != "-rf" {
+		t.Errorf("expected -- to end flags, got %v", args)
+	}
+	if _, _, err := parseFlags([]string{"-i", "file"}, "rf"); err == nil {
+		t.Error("expected error for unsupported flag")
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	dir := t.TempDir()
+	noFlags := map[rune]bool{}
+
+	if err := builtinMkdir(dir, noFlags, []string{"a/b"}); err == nil {
+		t.Error("expected mkdir without -p to fail for missing parents")
+	}
+	if err := builtinMkdir(dir, map[rune]bool{'p': true}, []string{"a/b"}); err != nil {
+		t.Fatalf("mkdir -p failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a", "b", "main.cpp"), []byte("int main() {}\n"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	if err := builtinCp(dir, noFlags, []string{"a", "copy"}); err == nil {
+		t.Error("expected cp without -r to fail for directories")
+	}
+	if err := builtinCp(dir, map[rune]bool{'r': true}, []string{"a", "copy"}); err != nil {
+		t.Fatalf("cp -r failed: %v", err)
+	}
+	if err := builtinCp(dir, noFlags, []string{"a/b/main.cpp", "copy"}); err != nil {
+		t.Fatalf("cp into directory failed: %v", err)
+	}
+	for _, file := range []string{"copy/b/main.cpp", "copy/main.cpp"} {
+		if data, err := os.ReadFile(filepath.Join(dir, file)); err != nil || string(data) != "int main() {}\n" {
+			t.Errorf("expected %s to be copied, got %q, %v", file, data, err)
+		}
+	}
+
+	if err := builtinRm(dir, noFlags, []string{"copy"}); err == nil {
+		t.Error("expected rm without -r to fail for directories")
+	}
+	if err := builtinRm(dir, noFlags, []string{"missing"}); err == nil {
+		t.Error("expected rm without -f to fail for missing files")
+	}
+	if err := builtinRm(dir, map[rune]bool{'r': true, 'f': true}, []string{"copy", "missing"}); err != nil {
+		t.Fatalf("rm -rf failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "copy")); !os.IsNotExist(err) {
+		t.Error("expected copy to be removed")
+	}
+}
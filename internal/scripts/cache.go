@@ -0,0 +1,108 @@
+package scripts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/michxymi/cppenv/internal/config"
+	"github.com/michxymi/cppenv/internal/environment"
+)
+
+// unsafeFileChars are replaced in script names to form cache file names
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// fingerprint hashes everything that decides what a cached script produces:
+// its command line, shell, working directory and the env it runs with, the
+// tool versions and the contents of its inputs
+// The env is the one the runner applies, so project [env] values, env files
+// and hermetic mode all count
+func (r *Runner) fingerprint(script config.Script, cmdline string) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "cmd %q\nshell %q\ncwd %q\n", cmdline, script.Shell, script.Cwd)
+
+	env := environment.MergeEnv(r.Env, script.Env)
+	env = append([]string(nil), env...)
+	sort.Strings(env)
+	for _, e := range env {
+		fmt.Fprintf(h, "env %q\n", e)
+	}
+	for _, name := range sortedKeys(r.Tools) {
+		tool := r.Tools[name]
+		fmt.Fprintf(h, "tool %q %q %q\n", name, tool.Version, tool.LLVM)
+	}
+
+	inputs, err := Glob(r.Root, script.Inputs)
+	if err != nil {
+		return "", err
+	}
+	for _, input := range inputs {
+		sum, err := hashFile(filepath.Join(r.Root, filepath.FromSlash(input)))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "input %q %s\n", input, sum)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// upToDate reports whether a script can be skipped: its outputs exist and
+// its fingerprint matches the one stored after its last successful run
+func (r *Runner) upToDate(name string, script config.Script, fingerprint string) (bool, error) {
+	stored, err := os.ReadFile(r.cacheFile(name))
+	if err != nil || strings.TrimSpace(string(stored)) != fingerprint {
+		return false, nil
+	}
+	for _, output := range script.Outputs {
+		exists, err := globExists(r.Root, output)
+		if err != nil || !exists {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// saveFingerprint stores the fingerprint of a script that ran successfully
+func (r *Runner) saveFingerprint(name, fingerprint string) error {
+	if err := os.MkdirAll(r.CacheDir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := os.WriteFile(r.cacheFile(name), []byte(fingerprint+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	return nil
+}
+
+func (r *Runner) cacheFile(name string) string {
+	return filepath.Join(r.CacheDir, unsafeFileChars.ReplaceAllString(name, "_"))
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
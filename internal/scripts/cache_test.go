@@ -0,0 +1,114 @@
+package scripts
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/michxymi/cppenv/internal/config"
+)
+
+func TestRunnerCache(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("scripts use POSIX shell syntax")
+	}
+
+	root := t.TempDir()
+	input := filepath.Join(root, "src", "main.cpp")
+	if err := os.MkdirAll(filepath.Dir(input), 0755); err != nil {
+		t.Fatalf("failed to create src: %v", err)
+	}
+	if err := os.WriteFile(input, []byte("int main() {}\n"), 0644); err != nil {
+		t.Fatalf("failed to write input: %v", err)
+	}
+
+	var stdout bytes.Buffer
+	runner := &Runner{
+		Scripts: map[string]config.Script{
+			"gen": {
+				Cmd:     "echo generated >> log && cp src/main.cpp out.cpp",
+				Inputs:  []string{"src/**/*.cpp"},
+				Outputs: []string{"out.cpp"},
+			},
+			// edit changes its input while it runs, like an editor saving
+			// during a long build
+			"edit": {
+				Cmd:    "echo generated >> log && echo '// edited' >> src/main.cpp",
+				Inputs: []string{"src/**/*.cpp"},
+			},
+		},
+		Env:      os.Environ(),
+		Root:     root,
+		CacheDir: filepath.Join(root, ".cppenv", "cache", "scripts"),
+		Tools:    map[string]config.Tool{"cmake": {Version: "3.28.1"}},
+		Stdout:   &stdout,
+	}
+
+	runs := func() int {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(root, "log"))
+		if err != nil {
+			t.Fatalf("failed to read log: %v", err)
+		}
+		return strings.Count(string(data), "generated")
+	}
+	run := func() {
+		t.Helper()
+		if code, err := runner.Run("gen", nil); err != nil || code != 0 {
+			t.Fatalf("Run() returned %d, %v", code, err)
+		}
+	}
+
+	run()
+	run()
+	if runs() != 1 {
+		t.Errorf("expected the second run to be skipped, ran %d times", runs())
+	}
+
+	if err := os.WriteFile(input, []byte("int main() { return 0; }\n"), 0644); err != nil {
+		t.Fatalf("failed to write input: %v", err)
+	}
+	run()
+	if runs() != 2 {
+		t.Errorf("expected a changed input to run the script, ran %d times", runs())
+	}
+
+	os.Remove(filepath.Join(root, "out.cpp"))
+	run()
+	if runs() != 3 {
+		t.Errorf("expected a missing output to run the script, ran %d times", runs())
+	}
+
+	runner.Tools = map[string]config.Tool{"cmake": {Version: "3.29.0"}}
+	run()
+	if runs() != 4 {
+		t.Errorf("expected a changed tool version to run the script, ran %d times", runs())
+	}
+
+	// Project [env] values and env files reach the runner through its env
+	runner.Env = append(os.Environ(), "CXXFLAGS=-O2")
+	run()
+	run()
+	if runs() != 5 {
+		t.Errorf("expected a changed env to run the script once, ran %d times", runs())
+	}
+
+	runner.Force = true
+	run()
+	if runs() != 6 {
+		t.Errorf("expected --force to run the script, ran %d times", runs())
+	}
+
+	runner.Force = false
+	for range 2 {
+		if code, err := runner.Run("edit", nil); err != nil || code != 0 {
+			t.Fatalf("Run() returned %d, %v", code, err)
+		}
+	}
+	if runs() != 8 {
+		t.Errorf("expected an input changed during the run to run the script again, ran %d times", runs())
+	}
+}
@@ -0,0 +1,87 @@
+package scripts
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// skippedDirs are never searched when expanding globs
+var skippedDirs = map[string]bool{".cppenv": true, ".git": true}
+
+// Glob returns the files under root matching any of the patterns, as sorted
+// slash-separated paths relative to root
+// Patterns use path.Match syntax plus "**", which matches any number of
+// directories
+func Glob(root string, patterns []string) ([]string, error) {
+	for _, pattern := range patterns {
+		if _, err := path.Match(filepath.ToSlash(pattern), ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+		}
+	}
+	split := splitPatterns(patterns)
+
+	var matches []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if skippedDirs[d.Name()] && p != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if matchAny(split, strings.Split(filepath.ToSlash(rel), "/")) {
+			matches = append(matches, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// globExists reports whether a pattern matches anything under root
+// Patterns without wildcards may also name a directory
+func globExists(root, pattern string) (bool, error) {
+	if !strings.ContainsAny(pattern, "*?[") {
+		_, err := os.Stat(filepath.Join(root, filepath.FromSlash(pattern)))
+		return err == nil, nil
+	}
+	matches, err := Glob(root, []string{pattern})
+	return len(matches) > 0, err
+}
+
+// matchParts matches path segments against pattern segments
+func matchParts(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchParts(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+		return false
+	}
+	return matchParts(pattern[1:], parts[1:])
+}
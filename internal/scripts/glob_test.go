@@ -0,0 +1,54 @@
+package scripts
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGlob(t *testing.T) {
+	root := t.TempDir()
+	for _, file := range []string{
+		"CMakeLists.txt",
+		"src/main.cpp",
+		"src/util/strings.cpp",
+		"src/util/strings.h",
+		".cppenv/venv/lib/site.cpp",
+		"build/gen.cpp",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", file, err)
+		}
+	}
+
+	tests := map[string]struct {
+		patterns []string
+		expected []string
+	}{
+		"single directory": {[]string{"src/*.cpp"}, []string{"src/main.cpp"}},
+		"recursive":        {[]string{"src/**/*.cpp"}, []string{"src/main.cpp", "src/util/strings.cpp"}},
+		"anywhere":         {[]string{"**/*.cpp"}, []string{"build/gen.cpp", "src/main.cpp", "src/util/strings.cpp"}},
+		"literal":          {[]string{"./CMakeLists.txt", "src/util/*.h"}, []string{"CMakeLists.txt", "src/util/strings.h"}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			matches, err := Glob(root, tt.patterns)
+			if err != nil {
+				t.Fatalf("Glob() failed: %v", err)
+			}
+			if !reflect.DeepEqual(matches, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, matches)
+			}
+		})
+	}
+
+	if _, err := Glob(root, []string{"src/[.cpp"}); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
@@ -0,0 +1,108 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/michxymi/cppenv/internal/environment"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// runBuiltinShell runs a command line with the built-in POSIX shell, so that
+// scripts behave the same on every platform
+// Programs run in the project environment like any other command; rm, mkdir
+// and cp are built in so they work on Windows as well
+func (r *Runner) runBuiltinShell(cmdline string, env []string, dir string) (int, error) {
+	file, err := syntax.NewParser().Parse(strings.NewReader(cmdline), "")
+	if err != nil {
+		return 1, fmt.Errorf("failed to parse script: %w", err)
+	}
+
+	shell, err := interp.New(
+		interp.Env(expand.ListEnviron(env...)),
+		interp.Dir(dir),
+		interp.StdIO(r.stdin(), r.stdout(), r.stderr()),
+		interp.ExecHandlers(builtinCommands, func(interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+			return r.execCommand
+		}),
+	)
+	if err != nil {
+		return 1, fmt.Errorf("failed to start shell: %w", err)
+	}
+
+	ctx := r.context()
+	err = shell.Run(ctx, file)
+	if status, ok := interp.IsExitStatus(err); ok {
+		return int(status), nil
+	}
+	var interrupted *interruptedError
+	if errors.As(err, &interrupted) {
+		return interrupted.code, nil
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return 1, nil
+		}
+		return 1, err
+	}
+	return 0, nil
+}
+
+// execCommand runs a program found on the shell's PATH
+func (r *Runner) execCommand(ctx context.Context, args []string) error {
+	hc := interp.HandlerCtx(ctx)
+	path, err := interp.LookPathDir(hc.Dir, hc.Env, args[0])
+	if err != nil {
+		fmt.Fprintln(hc.Stderr, err)
+		return interp.NewExitStatus(127)
+	}
+
+	var env []string
+	hc.Env.Each(func(name string, vr expand.Variable) bool {
+		if vr.Exported && vr.IsSet() {
+			env = append(env, name+"="+vr.String())
+		}
+		return true
+	})
+
+	cmd := &environment.Command{
+		Args:   append([]string{path}, args[1:]...),
+		Env:    env,
+		Dir:    hc.Dir,
+		Stdin:  hc.Stdin,
+		Stdout: hc.Stdout,
+		Stderr: hc.Stderr,
+	}
+	code := cmd.RunContext(ctx)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if cmd.Interrupted {
+		return &interruptedError{code: code}
+	}
+	return exitStatus(code)
+}
+
+// exitStatus returns the shell exit status for a command's exit code
+// Exit codes are 32 bits on Windows; those that are multiples of 256 would
+// otherwise read as success
+func exitStatus(code int) error {
+	if code != 0 && uint8(code) == 0 {
+		code = 1
+	}
+	return interp.NewExitStatus(uint8(code))
+}
+
+// interruptedError stops a script when one of its commands was interrupted
+// with Ctrl-C, as a shell would exit when it receives the interrupt too
+type interruptedError struct {
+	code int
+}
+
+func (e *interruptedError) Error() string {
+	return "interrupted"
+}
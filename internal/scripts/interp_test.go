@@ -0,0 +1,70 @@
+package scripts
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/michxymi/cppenv/internal/config"
+	"mvdan.cc/sh/v3/interp"
+)
+
+func TestBuiltinShell(t *testing.T) {
+	root := t.TempDir()
+	for _, file := range []string{"src/a.cpp", "src/b.cpp", "src/c.h"} {
+		path := filepath.Join(root, filepath.FromSlash(file))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", file, err)
+		}
+	}
+
+	var stdout bytes.Buffer
+	runner := &Runner{
+		Scripts: map[string]config.Script{
+			"clean": {Cmd: "mkdir -p build/out && cp src/*.cpp build/out && echo build/out/* && rm -rf build"},
+			"args":  {Cmd: `for arg in "$@"; do echo "[$arg]"; done`},
+			"fail":  {Cmd: "exit 3"},
+			"parse": {Cmd: "echo 'unterminated"},
+		},
+		Env:    os.Environ(),
+		Root:   root,
+		Stdout: &stdout,
+	}
+
+	if code, err := runner.Run("clean", nil); err != nil || code != 0 {
+		t.Fatalf("Run() returned %d, %v", code, err)
+	}
+	if !strings.Contains(stdout.String(), "build/out/a.cpp build/out/b.cpp\n") {
+		t.Errorf("expected globs to expand the same on every platform, got:\n%s", stdout.String())
+	}
+	if _, err := os.Stat(filepath.Join(root, "build")); !os.IsNotExist(err) {
+		t.Error("expected build to be removed")
+	}
+
+	stdout.Reset()
+	if code, err := runner.Run("args", []string{"a b", "c"}); err != nil || code != 0 {
+		t.Fatalf("Run() returned %d, %v", code, err)
+	}
+	if !strings.Contains(stdout.String(), "[a b]\n[c]\n") {
+		t.Errorf("expected arguments to be passed intact, got:\n%s", stdout.String())
+	}
+
+	if code, _ := runner.Run("fail", nil); code != 3 {
+		t.Errorf("expected exit code 3, got %d", code)
+	}
+	if _, err := runner.Run("parse", nil); err == nil {
+		t.Error("expected error for a script that does not parse")
+	}
+}
+
+func TestExitStatus(t *testing.T) {
+	for code, want := range map[int]uint8{0: 0, 3: 3, 256: 1, 512: 1, 259: 3} {
+		status, _ := interp.IsExitStatus(exitStatus(code))
+		if status != want {
+			t.Errorf("exitStatus(%d) = %d, expected %d", code, status, want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package scripts
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// Output modes for scripts that run in parallel
+const (
+	// OutputPrefix writes each line as it arrives, prefixed with the script name
+	OutputPrefix = "prefix"
+	// OutputBuffer holds a script's output until it finishes
+	OutputBuffer = "buffer"
+)
+
+// prefixWriter writes complete lines to w with a prefix, so that lines from
+// concurrent scripts do not get mixed up
+// Writers that share mu never write to their targets at the same time
+type prefixWriter struct {
+	w      io.Writer
+	prefix string
+	mu     *sync.Mutex
+	buf    []byte
+}
+
+func newPrefixWriter(w io.Writer, prefix string, mu *sync.Mutex) *prefixWriter {
+	return &prefixWriter{w: w, prefix: prefix, mu: mu}
+}
+
+func (p *prefixWriter) Write(data []byte) (int, error) {
+	p.buf = append(p.buf, data...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := p.writeLine(p.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		p.buf = p.buf[i+1:]
+	}
+	return len(data), nil
+}
+
+// Flush writes a final line that has no trailing newline
+func (p *prefixWriter) Flush() error {
+	if len(p.buf) == 0 {
+		return nil
+	}
+	line := append(p.buf, '\n')
+	p.buf = nil
+	return p.writeLine(line)
+}
+
+func (p *prefixWriter) writeLine(line []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err := io.WriteString(p.w, p.prefix+string(line))
+	return err
+}
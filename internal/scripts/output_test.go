@@ -0,0 +1,26 @@
+package scripts
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	var out bytes.Buffer
+	var mu sync.Mutex
+	w := newPrefixWriter(&out, "[lint] ", &mu)
+
+	w.Write([]byte("first\nsec"))
+	w.Write([]byte("ond\nthird"))
+	if out.String() != "[lint] first\n[lint] second\n" {
+		t.Errorf("expected only complete lines before Flush, got %q", out.String())
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() failed: %v", err)
+	}
+	if out.String() != "[lint] first\n[lint] second\n[lint] third\n" {
+		t.Errorf("expected the last line after Flush, got %q", out.String())
+	}
+}
@@ -0,0 +1,53 @@
+package scripts
+
+import (
+	"fmt"
+
+	"github.com/michxymi/cppenv/internal/config"
+)
+
+// Resolve returns the scripts to run for name, in order: each dependency
+// before the scripts that depend on it, every script once, and name last
+func Resolve(scripts map[string]config.Script, name string) ([]string, error) {
+	if err := config.CheckScriptCycles(scripts); err != nil {
+		return nil, err
+	}
+
+	done := make(map[string]bool)
+	var order []string
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		if done[name] {
+			return nil
+		}
+
+		script, ok := scripts[name]
+		if !ok {
+			if len(path) > 0 {
+				return fmt.Errorf("script %q depends on unknown script %q", path[len(path)-1], name)
+			}
+			return unknownScriptError(scripts, name)
+		}
+		if err := script.Err(); err != nil {
+			return err
+		}
+
+		path = append(path, name)
+		for _, dep := range script.Depends {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		done[name] = true
+		order = append(order, name)
+		return nil
+	}
+
+	if err := visit(name); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
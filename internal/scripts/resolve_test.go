@@ -0,0 +1,64 @@
+package scripts
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/michxymi/cppenv/internal/config"
+)
+
+func TestResolve(t *testing.T) {
+	scripts := map[string]config.Script{
+		"configure": {Cmd: "cmake -B build"},
+		"build":     {Cmd: "cmake --build build", Depends: []string{"configure"}},
+		"test":      {Cmd: "ctest --test-dir build", Depends: []string{"build", "configure"}},
+		"lint":      {Cmd: "clang-tidy"},
+	}
+
+	tests := map[string][]string{
+		"lint":      {"lint"},
+		"configure": {"configure"},
+		"build":     {"configure", "build"},
+		"test":      {"configure", "build", "test"},
+	}
+
+	for name, want := range tests {
+		got, err := Resolve(scripts, name)
+		if err != nil {
+			t.Fatalf("Resolve(%q) failed: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Resolve(%q) = %v, expected %v", name, got, want)
+		}
+	}
+}
+
+func TestResolveCycle(t *testing.T) {
+	scripts := map[string]config.Script{
+		"a": {Cmd: "a", Depends: []string{"b"}},
+		"b": {Cmd: "b", Depends: []string{"c"}},
+		"c": {Cmd: "c", Depends: []string{"b"}},
+	}
+
+	_, err := Resolve(scripts, "a")
+	if err == nil {
+		t.Fatal("expected error for dependency cycle")
+	}
+	if !strings.Contains(err.Error(), "b -> c -> b") {
+		t.Errorf("expected cycle b -> c -> b in error, got %v", err)
+	}
+}
+
+func TestResolveUnknown(t *testing.T) {
+	scripts := map[string]config.Script{
+		"build": {Cmd: "make", Depends: []string{"missing"}},
+	}
+
+	if _, err := Resolve(scripts, "build"); err == nil {
+		t.Error("expected error for unknown dependency")
+	}
+	if _, err := Resolve(scripts, "missing"); err == nil {
+		t.Error("expected error for unknown script")
+	}
+}
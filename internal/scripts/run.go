@@ -0,0 +1,430 @@
+package scripts
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/michxymi/cppenv/internal/config"
+	"github.com/michxymi/cppenv/internal/environment"
+)
+
+// Runner runs scripts from cppenv.toml in the project environment
+type Runner struct {
+	Scripts map[string]config.Script
+	// Env is the environment scripts start from; script env values are added to it
+	Env []string
+	// Root is the directory relative script working directories are resolved against
+	Root string
+
+	// Jobs limits how many steps of a parallel script run at once;
+	// it defaults to the number of CPUs
+	Jobs int
+	// Output is OutputPrefix or OutputBuffer and sets how the output of
+	// parallel steps is shown; it defaults to OutputPrefix
+	Output string
+
+	// CacheDir holds the fingerprints of scripts with inputs; scripts are not
+	// cached if it is empty
+	CacheDir string
+	// Force runs cached scripts even if they are up to date
+	Force bool
+	// Tools are the configured tools, whose versions are part of fingerprints
+	Tools map[string]config.Tool
+
+	// Context stops running commands when it is canceled; it may be nil
+	Context context.Context
+
+	// Stdin, Stdout and Stderr default to the process's own
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	state *runState
+}
+
+// runState tracks the scripts started in one invocation, shared by all the
+// goroutines running its steps
+type runState struct {
+	mu   sync.Mutex
+	runs map[string]*scriptRun
+}
+
+// scriptRun is a script that was started; done is closed once code is set
+type scriptRun struct {
+	done chan struct{}
+	code int
+}
+
+// Run runs a script after its dependencies, passing args to the script itself
+// Every script runs at most once, even if several scripts depend on it
+// It stops at the first script that fails and returns its exit code
+func (r *Runner) Run(name string, args []string) (int, error) {
+	r.state = &runState{runs: make(map[string]*scriptRun)}
+	return r.run(name, args)
+}
+
+// RunParallel runs several scripts and their dependencies concurrently
+// All scripts run even if one fails; the exit code is that of the first
+// script in names that failed
+func (r *Runner) RunParallel(names []string) (int, error) {
+	steps := make([]config.Step, len(names))
+	for i, name := range names {
+		if _, ok := r.Scripts[name]; !ok {
+			return 1, unknownScriptError(r.Scripts, name)
+		}
+		steps[i] = config.Step{Script: name}
+	}
+
+	r.state = &runState{runs: make(map[string]*scriptRun)}
+	script := config.Script{Steps: steps, Parallel: true, KeepGoing: true}
+	return r.runSteps(strings.Join(names, ", "), script)
+}
+
+func (r *Runner) run(name string, args []string) (int, error) {
+	order, err := Resolve(r.Scripts, name)
+	if err != nil {
+		return 1, err
+	}
+
+	for _, current := range order {
+		var scriptArgs []string
+		if current == name {
+			scriptArgs = args
+		}
+		code, err := r.runOnce(current, scriptArgs)
+		if err != nil || code != 0 {
+			return code, err
+		}
+	}
+	return 0, nil
+}
+
+// runOnce runs a script without its dependencies unless it was already
+// started in this invocation, in which case it waits for that run to finish
+func (r *Runner) runOnce(name string, args []string) (int, error) {
+	r.state.mu.Lock()
+	if started, ok := r.state.runs[name]; ok {
+		r.state.mu.Unlock()
+		<-started.done
+		return started.code, nil
+	}
+	current := &scriptRun{done: make(chan struct{})}
+	r.state.runs[name] = current
+	r.state.mu.Unlock()
+
+	code, err := r.runScript(name, r.Scripts[name], args)
+	if err != nil {
+		code = 1
+		err = fmt.Errorf("script %q: %w", name, err)
+	}
+	current.code = code
+	close(current.done)
+	return code, err
+}
+
+// runScript runs a single script without its dependencies
+func (r *Runner) runScript(name string, script config.Script, args []string) (int, error) {
+	if len(script.Steps) > 0 {
+		if len(args) > 0 {
+			return 1, fmt.Errorf("scripts with steps do not take arguments")
+		}
+		return r.runSteps(name, script)
+	}
+	if r.CacheDir != "" && len(script.Inputs) > 0 {
+		return r.runCached(name, script, args)
+	}
+	return r.runCommand(script, script.Cmd, args)
+}
+
+// runCached runs a script with inputs unless it is up to date, and stores
+// its fingerprint when it succeeds
+// The fingerprint is taken before the run, so inputs edited while it runs
+// make the next run start again
+func (r *Runner) runCached(name string, script config.Script, args []string) (int, error) {
+	cmdline, err := withShellArgs(script.Shell, script.Cmd, args)
+	if err != nil {
+		return 1, err
+	}
+
+	fingerprint, err := r.fingerprint(script, cmdline)
+	if err != nil {
+		return 1, err
+	}
+	if !r.Force {
+		upToDate, err := r.upToDate(name, script, fingerprint)
+		if err != nil {
+			return 1, err
+		}
+		if upToDate {
+			fmt.Fprintf(r.stdout(), "✓ %s is up to date\n", name)
+			return 0, nil
+		}
+	}
+
+	code, err := r.runCommand(script, script.Cmd, args)
+	if err != nil || code != 0 {
+		return code, err
+	}
+	return 0, r.saveFingerprint(name, fingerprint)
+}
+
+// runCommand runs a command with a script's shell, working directory and env
+// Scripts without a shell setting run in the built-in shell
+func (r *Runner) runCommand(script config.Script, command string, args []string) (int, error) {
+	cmdline, err := withShellArgs(script.Shell, command, args)
+	if err != nil {
+		return 1, err
+	}
+
+	dir := r.Root
+	if script.Cwd != "" {
+		dir = script.Cwd
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(r.Root, dir)
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return 1, fmt.Errorf("working directory %s does not exist", dir)
+		}
+	}
+	env := environment.MergeEnv(r.Env, script.Env)
+
+	if script.Shell == "" {
+		fmt.Fprintf(r.stdout(), "→ %s\n", cmdline)
+		return r.runBuiltinShell(cmdline, env, dir)
+	}
+
+	shellArgs, err := shellCommand(script.Shell, cmdline)
+	if err != nil {
+		return 1, err
+	}
+	fmt.Fprintf(r.stdout(), "→ %s\n", cmdline)
+	cmd := &environment.Command{
+		Args:   shellArgs,
+		Env:    env,
+		Dir:    dir,
+		Stdin:  r.stdin(),
+		Stdout: r.stdout(),
+		Stderr: r.stderr(),
+	}
+	return cmd.RunContext(r.context()), nil
+}
+
+// runStep runs one step of a script and returns its exit code
+// Errors are reported on stderr and count as exit code 1
+func (r *Runner) runStep(script config.Script, step config.Step) int {
+	var code int
+	var err error
+	if step.Script != "" {
+		code, err = r.run(step.Script, nil)
+	} else {
+		code, err = r.runCommand(script, step.Cmd, nil)
+	}
+	if err != nil {
+		fmt.Fprintf(r.stderr(), "Error: %v\n", err)
+		return 1
+	}
+	return code
+}
+
+// stepResult is the outcome of one step of a multi-step script
+type stepResult struct {
+	step     config.Step
+	code     int
+	ran      bool
+	duration time.Duration
+}
+
+// runSteps runs the steps of a script with a header for each step, then
+// prints a summary
+// Without keep-going no more steps are started once one fails; the exit code
+// is that of the first step that failed
+func (r *Runner) runSteps(name string, script config.Script) (int, error) {
+	var results []stepResult
+	if script.Parallel {
+		results = r.runParallelSteps(script)
+	} else {
+		results = r.runSequentialSteps(script)
+	}
+
+	r.printSummary(name, results)
+	for _, result := range results {
+		if result.code != 0 {
+			return result.code, nil
+		}
+	}
+	return 0, nil
+}
+
+func (r *Runner) runSequentialSteps(script config.Script) []stepResult {
+	results := make([]stepResult, len(script.Steps))
+	failed := false
+
+	for i, step := range script.Steps {
+		results[i].step = step
+		if failed && !script.KeepGoing {
+			continue
+		}
+
+		fmt.Fprintf(r.stdout(), "\n[%d/%d] %s\n", i+1, len(script.Steps), step)
+		start := time.Now()
+		code := r.runStep(script, step)
+		results[i] = stepResult{step: step, code: code, ran: true, duration: time.Since(start)}
+		failed = failed || code != 0
+	}
+	return results
+}
+
+// runParallelSteps runs the steps of a script concurrently, at most r.Jobs at
+// a time, starting them in order
+func (r *Runner) runParallelSteps(script config.Script) []stepResult {
+	results := make([]stepResult, len(script.Steps))
+	labels := stepLabels(script.Steps)
+	jobs := r.Jobs
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var failed atomic.Bool
+	slots := make(chan struct{}, jobs)
+
+	for i, step := range script.Steps {
+		results[i].step = step
+		slots <- struct{}{}
+		if failed.Load() && !script.KeepGoing {
+			<-slots
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			header := fmt.Sprintf("[%d/%d] %s", i+1, len(script.Steps), step)
+			start := time.Now()
+			code := r.runParallelStep(script, step, header, labels[i], &mu)
+			results[i] = stepResult{step: step, code: code, ran: true, duration: time.Since(start)}
+			if code != 0 {
+				failed.Store(true)
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// runParallelStep runs a step with its output prefixed or buffered, using mu
+// to keep it from mixing with the output of the other steps
+func (r *Runner) runParallelStep(script config.Script, step config.Step, header, label string, mu *sync.Mutex) int {
+	// Steps running side by side cannot share the terminal's input
+	child := *r
+	child.Stdin = strings.NewReader("")
+
+	if r.Output == OutputBuffer {
+		var buf bytes.Buffer
+		child.Stdout = &buf
+		child.Stderr = &buf
+		code := child.runStep(script, step)
+
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(r.stdout(), "\n%s\n", header)
+		r.stdout().Write(buf.Bytes())
+		return code
+	}
+
+	stdout := newPrefixWriter(r.stdout(), label, mu)
+	stderr := newPrefixWriter(r.stderr(), label, mu)
+	child.Stdout = stdout
+	child.Stderr = stderr
+	stdout.Write([]byte(header + "\n"))
+	code := child.runStep(script, step)
+	stdout.Flush()
+	stderr.Flush()
+	return code
+}
+
+// stepLabels returns the line prefixes for parallel steps, padded to the same
+// width: the script name, or the step number for commands
+func stepLabels(steps []config.Step) []string {
+	labels := make([]string, len(steps))
+	width := 0
+	for i, step := range steps {
+		labels[i] = step.Script
+		if labels[i] == "" {
+			labels[i] = fmt.Sprintf("step %d", i+1)
+		}
+		width = max(width, len(labels[i]))
+	}
+	for i, label := range labels {
+		labels[i] = fmt.Sprintf("[%-*s] ", width, label)
+	}
+	return labels
+}
+
+func (r *Runner) printSummary(name string, results []stepResult) {
+	width := 0
+	for _, result := range results {
+		width = max(width, len(result.step.String()))
+	}
+
+	fmt.Fprintf(r.stdout(), "\nSummary for %s:\n", name)
+	for _, result := range results {
+		label := result.step.String()
+		switch {
+		case !result.ran:
+			fmt.Fprintf(r.stdout(), "  - %-*s  skipped\n", width, label)
+		case result.code != 0:
+			fmt.Fprintf(r.stdout(), "  ✗ %-*s  %s (exit code %d)\n", width, label, formatDuration(result.duration), result.code)
+		default:
+			fmt.Fprintf(r.stdout(), "  ✓ %-*s  %s\n", width, label, formatDuration(result.duration))
+		}
+	}
+}
+
+// formatDuration rounds a duration for display
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
+
+func (r *Runner) context() context.Context {
+	if r.Context == nil {
+		return context.Background()
+	}
+	return r.Context
+}
+
+func (r *Runner) stdin() io.Reader {
+	if r.Stdin == nil {
+		return os.Stdin
+	}
+	return r.Stdin
+}
+
+func (r *Runner) stdout() io.Writer {
+	if r.Stdout == nil {
+		return os.Stdout
+	}
+	return r.Stdout
+}
+
+func (r *Runner) stderr() io.Writer {
+	if r.Stderr == nil {
+		return os.Stderr
+	}
+	return r.Stderr
+}
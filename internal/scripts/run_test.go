@@ -0,0 +1,247 @@
+package scripts
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/michxymi/cppenv/internal/config"
+)
+
+func TestRunnerRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("scripts use POSIX shell syntax")
+	}
+
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "build"), 0755); err != nil {
+		t.Fatalf("failed to create build directory: %v", err)
+	}
+
+	var stdout bytes.Buffer
+	runner := &Runner{
+		Scripts: map[string]config.Script{
+			"configure": {Cmd: "echo configure"},
+			"build": {
+				Cmd:     `echo "build in $(basename "$PWD") at $LEVEL"`,
+				Cwd:     "build",
+				Env:     map[string]string{"LEVEL": "8"},
+				Depends: []string{"configure"},
+			},
+		},
+		Env:    os.Environ(),
+		Root:   root,
+		Stdout: &stdout,
+	}
+
+	code, err := runner.Run("build", []string{"extra"})
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+
+	output := stdout.String()
+	configureIdx := strings.Index(output, "configure\n")
+	buildIdx := strings.Index(output, "build in build at 8 extra\n")
+	if configureIdx < 0 || buildIdx < 0 || configureIdx > buildIdx {
+		t.Errorf("expected configure to run before build with cwd, env and args applied, got:\n%s", output)
+	}
+}
+
+func TestRunnerStopsOnFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("scripts use POSIX shell syntax")
+	}
+
+	var stdout bytes.Buffer
+	runner := &Runner{
+		Scripts: map[string]config.Script{
+			"configure": {Cmd: "exit 3"},
+			"build":     {Cmd: "echo should-not-run", Depends: []string{"configure"}},
+		},
+		Env:    os.Environ(),
+		Root:   t.TempDir(),
+		Stdout: &stdout,
+	}
+
+	code, err := runner.Run("build", nil)
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if code != 3 {
+		t.Errorf("expected exit code 3, got %d", code)
+	}
+	if strings.Contains(stdout.String(), "should-not-run\n") {
+		t.Error("expected build not to run after configure failed")
+	}
+}
+
+func TestRunnerSteps(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("scripts use POSIX shell syntax")
+	}
+
+	var stdout bytes.Buffer
+	runner := &Runner{
+		Scripts: map[string]config.Script{
+			"configure": {Cmd: "echo configure-ran"},
+			"build":     {Cmd: "echo build-ran", Depends: []string{"configure"}},
+			"test":      {Cmd: "exit 4"},
+			"ci": {Steps: []config.Step{
+				{Script: "configure"},
+				{Script: "build"},
+				{Script: "test"},
+				{Cmd: "echo after-test"},
+			}},
+		},
+		Env:    os.Environ(),
+		Root:   t.TempDir(),
+		Stdout: &stdout,
+	}
+
+	code, err := runner.Run("ci", nil)
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if code != 4 {
+		t.Errorf("expected exit code 4, got %d", code)
+	}
+
+	output := stdout.String()
+	if strings.Count(output, "\nconfigure-ran\n") != 1 {
+		t.Errorf("expected configure to run once, got:\n%s", output)
+	}
+	if !strings.Contains(output, "[2/4] build") || !strings.Contains(output, "[3/4] test") {
+		t.Errorf("expected step headers, got:\n%s", output)
+	}
+	if strings.Contains(output, "\nafter-test\n") {
+		t.Error("expected steps after a failure to be skipped")
+	}
+	if !strings.Contains(output, "(exit code 4)") || !strings.Contains(output, "echo after-test  skipped") {
+		t.Errorf("expected summary with failed and skipped steps, got:\n%s", output)
+	}
+
+	if _, err := runner.Run("ci", []string{"extra"}); err == nil {
+		t.Error("expected error when passing arguments to a script with steps")
+	}
+}
+
+func TestRunnerStepsKeepGoing(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("scripts use POSIX shell syntax")
+	}
+
+	var stdout bytes.Buffer
+	runner := &Runner{
+		Scripts: map[string]config.Script{
+			"ci": {
+				Steps:     []config.Step{{Cmd: "exit 2"}, {Cmd: "exit 5"}, {Cmd: "echo last-step"}},
+				KeepGoing: true,
+			},
+		},
+		Env:    os.Environ(),
+		Root:   t.TempDir(),
+		Stdout: &stdout,
+	}
+
+	code, err := runner.Run("ci", nil)
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if code != 2 {
+		t.Errorf("expected exit code of the first failing step, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), "last-step\n") {
+		t.Errorf("expected remaining steps to run with keep-going, got:\n%s", stdout.String())
+	}
+}
+
+func TestRunnerRunParallel(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("scripts use POSIX shell syntax")
+	}
+
+	for _, output := range []string{OutputPrefix, OutputBuffer} {
+		t.Run(output, func(t *testing.T) {
+			var stdout bytes.Buffer
+			runner := &Runner{
+				Scripts: map[string]config.Script{
+					"configure": {Cmd: "echo configure-ran"},
+					"lint":      {Cmd: "echo lint-ran", Depends: []string{"configure"}},
+					"tidy":      {Cmd: "echo tidy-ran; exit 6", Depends: []string{"configure"}},
+					"format":    {Cmd: "exit 7"},
+				},
+				Env:    os.Environ(),
+				Root:   t.TempDir(),
+				Jobs:   2,
+				Output: output,
+				Stdout: &stdout,
+				Stderr: &stdout,
+			}
+
+			code, err := runner.RunParallel([]string{"lint", "tidy", "format"})
+			if err != nil {
+				t.Fatalf("RunParallel() failed: %v", err)
+			}
+			if code != 6 {
+				t.Errorf("expected exit code of the first failed script, got %d", code)
+			}
+
+			out := stdout.String()
+			if strings.Count(out, "configure-ran\n")-strings.Count(out, "echo configure-ran\n") != 1 {
+				t.Errorf("expected the shared dependency to run once, got:\n%s", out)
+			}
+			if output == OutputPrefix && !strings.Contains(out, "[lint  ] lint-ran\n") {
+				t.Errorf("expected prefixed output, got:\n%s", out)
+			}
+			if output == OutputBuffer {
+				_, block, _ := strings.Cut(out, "\n[2/3] tidy\n")
+				block, _, _ = strings.Cut(block, "\n\n")
+				if !strings.HasSuffix(block, "→ echo tidy-ran; exit 6\ntidy-ran") {
+					t.Errorf("expected buffered output after the header, got:\n%s", out)
+				}
+			}
+			if !strings.Contains(out, "(exit code 7)") {
+				t.Errorf("expected all scripts to run, got:\n%s", out)
+			}
+		})
+	}
+
+	runner := &Runner{Scripts: map[string]config.Script{}, Stdout: &bytes.Buffer{}}
+	if _, err := runner.RunParallel([]string{"missing"}); err == nil {
+		t.Error("expected error for unknown script")
+	}
+}
+
+func TestRunnerMissingCwd(t *testing.T) {
+	runner := &Runner{
+		Scripts: map[string]config.Script{
+			"build": {Cmd: "make", Cwd: "missing"},
+		},
+		Root:   t.TempDir(),
+		Stdout: &bytes.Buffer{},
+	}
+
+	if _, err := runner.Run("build", nil); err == nil {
+		t.Error("expected error for missing working directory")
+	}
+}
+
+func TestShellCommand(t *testing.T) {
+	if _, err := shellCommand("fish", "echo"); err == nil {
+		t.Error("expected error for unsupported shell")
+	}
+
+	args, err := shellCommand("bash", "echo hi")
+	if err != nil {
+		t.Fatalf("shellCommand() failed: %v", err)
+	}
+	if strings.Join(args, " ") != "bash -c echo hi" {
+		t.Errorf("unexpected command line %q", args)
+	}
+}
@@ -0,0 +1,54 @@
+package scripts
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/michxymi/cppenv/internal/environment"
+)
+
+// ShellSystem runs scripts with the platform's shell, sh or cmd, instead of
+// the built-in one
+const ShellSystem = "system"
+
+// shellCommand returns the command line that runs cmd with an external shell
+// "system" means the platform's default shell
+func shellCommand(shell, cmd string) ([]string, error) {
+	switch shell {
+	case ShellSystem:
+		return environment.ShellArgs(cmd), nil
+	case "sh", "bash", "zsh", "dash":
+		return []string{shell, "-c", cmd}, nil
+	case "cmd":
+		return []string{"cmd", "/c", cmd}, nil
+	case "powershell", "pwsh":
+		return []string{shell, "-NoProfile", "-Command", cmd}, nil
+	}
+	return nil, fmt.Errorf("unsupported shell %q", shell)
+}
+
+// withShellArgs adds extra arguments to cmd, quoted for the given shell
+func withShellArgs(shell, cmd string, args []string) (string, error) {
+	var quote func(string) string
+	switch shell {
+	case ShellSystem:
+		return WithArgs(cmd, args), nil
+	case "", "sh", "bash", "zsh", "dash":
+		quote = quotePOSIX
+	case "cmd":
+		quote = quoteWindows
+	case "powershell", "pwsh":
+		quote = quotePowerShell
+	default:
+		return "", fmt.Errorf("unsupported shell %q", shell)
+	}
+	return withArgs(cmd, args, quote), nil
+}
+
+// defaultQuote returns the quoting function for the platform's default shell
+func defaultQuote() func(string) string {
+	if runtime.GOOS == "windows" {
+		return quoteWindows
+	}
+	return quotePOSIX
+}
@@ -0,0 +1,79 @@
+package scripts
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/michxymi/cppenv/internal/config"
+)
+
+// minSuggestPrefix is the shortest name that suggests a script starting
+// with it
+const minSuggestPrefix = 3
+
+// Suggest returns the script whose name is closest to name, for "did you
+// mean" hints, or "" if none is close enough
+// A script is close if it is the only one starting with name, ignoring case,
+// or is at most two edits away
+func Suggest(scripts map[string]config.Script, name string) string {
+	if name == "" {
+		return ""
+	}
+	names := make([]string, 0, len(scripts))
+	for script := range scripts {
+		names = append(names, script)
+	}
+	sort.Strings(names)
+
+	lower := strings.ToLower(name)
+	if len(name) >= minSuggestPrefix {
+		var matches []string
+		for _, script := range names {
+			if strings.HasPrefix(strings.ToLower(script), lower) {
+				matches = append(matches, script)
+			}
+		}
+		if len(matches) == 1 {
+			return matches[0]
+		}
+	}
+
+	best, bestDistance := "", 3
+	for _, script := range names {
+		if d := editDistance(lower, strings.ToLower(script)); d < bestDistance {
+			best, bestDistance = script, d
+		}
+	}
+	return best
+}
+
+// unknownScriptError reports a script that does not exist, suggesting a
+// similar name if there is one
+func unknownScriptError(scripts map[string]config.Script, name string) error {
+	if suggestion := Suggest(scripts, name); suggestion != "" {
+		return fmt.Errorf("unknown script %q, did you mean %q?", name, suggestion)
+	}
+	return fmt.Errorf("unknown script %q", name)
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,38 @@
+package scripts
+
+import (
+	"testing"
+
+	"github.com/michxymi/cppenv/internal/config"
+)
+
+func TestSuggest(t *testing.T) {
+	scripts := map[string]config.Script{
+		"build":        {Cmd: "cmake --build build"},
+		"configure":    {Cmd: "cmake -B build"},
+		"check-format": {Cmd: "clang-format --dry-run src/*.cpp"},
+		"test":         {Cmd: "ctest"},
+		"test-unit":    {Cmd: "ctest -L unit"},
+		"lint":         {Cmd: "clang-tidy src/*.cpp"},
+	}
+
+	tests := map[string]string{
+		"biuld":  "build",
+		"tset":   "test",
+		"config": "configure",
+		"check":  "check-format",
+		"Build":  "build",
+		"CHECK":  "check-format",
+		"test-":  "test-unit",
+		"tes":    "test",
+		"l":      "",
+		"deploy": "",
+		"":       "",
+	}
+
+	for name, expected := range tests {
+		if got := Suggest(scripts, name); got != expected {
+			t.Errorf("Suggest(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+}
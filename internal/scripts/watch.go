@@ -0,0 +1,196 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long file changes have to settle before a re-run
+const watchDebounce = 200 * time.Millisecond
+
+// Watch runs a script and runs it again whenever files change, until ctx is
+// canceled
+// Only changes to the script's inputs count if it declares any; otherwise
+// every file in the project counts except its outputs, build directories and
+// paths ignored by git. A run that is still going when files change is
+// stopped along with all processes it started
+func (r *Runner) Watch(ctx context.Context, name string, args []string) error {
+	script, ok := r.Scripts[name]
+	if !ok {
+		return unknownScriptError(r.Scripts, name)
+	}
+	if err := script.Err(); err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch files: %w", err)
+	}
+	defer watcher.Close()
+
+	ignored := gitIgnored(r.Root)
+	if err := addWatches(watcher, r.Root, r.Root, ignored); err != nil {
+		return err
+	}
+	filter := &watchFilter{
+		inputs:  splitPatterns(script.Inputs),
+		outputs: splitPatterns(script.Outputs),
+		ignored: ignored,
+	}
+
+	// Runs get no input, so the terminal stays with cppenv and Ctrl-C stops
+	// watching rather than just the current run
+	r.Stdin = strings.NewReader("")
+
+	for {
+		runCtx, cancel := context.WithCancel(ctx)
+		finished := make(chan struct{})
+		r.Context = runCtx
+		go func() {
+			defer close(finished)
+			code, err := r.Run(name, args)
+			switch {
+			case runCtx.Err() != nil:
+			case err != nil:
+				fmt.Fprintf(r.stderr(), "Error: %v\n", err)
+			case code != 0:
+				fmt.Fprintf(r.stdout(), "✗ %s failed with exit code %d, waiting for changes...\n", name, code)
+			default:
+				fmt.Fprintf(r.stdout(), "✓ %s finished, waiting for changes...\n", name)
+			}
+		}()
+
+		changed, err := r.waitForChange(ctx, watcher, filter)
+		cancel()
+		<-finished
+		if err != nil || ctx.Err() != nil {
+			return err
+		}
+		fmt.Fprintf(r.stdout(), "\n↻ %s changed, running %s again\n", changed, name)
+	}
+}
+
+// waitForChange returns the first changed file once changes have settled
+func (r *Runner) waitForChange(ctx context.Context, watcher *fsnotify.Watcher, filter *watchFilter) (string, error) {
+	var changed string
+	var settled <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", nil
+		case err := <-watcher.Errors:
+			return "", fmt.Errorf("failed to watch files: %w", err)
+		case event := <-watcher.Events:
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					addWatches(watcher, r.Root, event.Name, filter.ignored)
+				}
+			}
+			rel, err := filepath.Rel(r.Root, event.Name)
+			if err != nil || !filter.matches(filepath.ToSlash(rel)) {
+				continue
+			}
+			if changed == "" {
+				changed = filepath.ToSlash(rel)
+			}
+			settled = time.After(watchDebounce)
+		case <-settled:
+			return changed, nil
+		}
+	}
+}
+
+// watchFilter decides which changed files trigger a re-run
+type watchFilter struct {
+	inputs  [][]string
+	outputs [][]string
+	// ignored holds the paths git ignores, relative to the project root;
+	// directories end in "/"
+	ignored map[string]bool
+}
+
+func (f *watchFilter) matches(rel string) bool {
+	parts := strings.Split(rel, "/")
+	if len(f.inputs) > 0 {
+		return matchAny(f.inputs, parts)
+	}
+	if matchAny(f.outputs, parts) || f.ignored[rel] {
+		return false
+	}
+	for i, part := range parts[:len(parts)-1] {
+		if isBuildDir(part) || f.ignored[strings.Join(parts[:i+1], "/")+"/"] {
+			return false
+		}
+	}
+	return true
+}
+
+// isBuildDir reports whether a directory is never watched
+func isBuildDir(name string) bool {
+	return skippedDirs[name] || name == "build" || strings.HasPrefix(name, "cmake-build-")
+}
+
+// addWatches watches dir and its subdirectories, except build and ignored ones
+func addWatches(watcher *fsnotify.Watcher, root, dir string, ignored map[string]bool) error {
+	return filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if p != root {
+			rel, _ := filepath.Rel(root, p)
+			if isBuildDir(d.Name()) || ignored[filepath.ToSlash(rel)+"/"] {
+				return filepath.SkipDir
+			}
+		}
+		if err := watcher.Add(p); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", p, err)
+		}
+		return nil
+	})
+}
+
+// gitIgnored returns the untracked paths git ignores under root, or nothing
+// outside a git repository
+func gitIgnored(root string) map[string]bool {
+	ignored := make(map[string]bool)
+	out, err := exec.Command("git", "-C", root, "ls-files", "--others", "--ignored", "--exclude-standard", "--directory").Output()
+	if err != nil {
+		return ignored
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if line != "" {
+			ignored[line] = true
+		}
+	}
+	return ignored
+}
+
+func splitPatterns(patterns []string) [][]string {
+	split := make([][]string, len(patterns))
+	for i, pattern := range patterns {
+		split[i] = strings.Split(path.Clean(filepath.ToSlash(pattern)), "/")
+	}
+	return split
+}
+
+func matchAny(patterns [][]string, parts []string) bool {
+	for _, pattern := range patterns {
+		if matchParts(pattern, parts) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package scripts
+
+import "testing"
+
+func TestWatchFilter(t *testing.T) {
+	withInputs := &watchFilter{inputs: splitPatterns([]string{"src/**/*.cpp", "CMakeLists.txt"})}
+	tree := &watchFilter{
+		outputs: splitPatterns([]string{"gen/*.h"}),
+		ignored: map[string]bool{"logs/": true, "notes.txt": true},
+	}
+
+	tests := []struct {
+		filter   *watchFilter
+		path     string
+		expected bool
+	}{
+		{withInputs, "src/main.cpp", true},
+		{withInputs, "src/util/strings.cpp", true},
+		{withInputs, "CMakeLists.txt", true},
+		{withInputs, "README.md", false},
+		{tree, "README.md", true},
+		{tree, "src/main.cpp", true},
+		{tree, "gen/version.h", false},
+		{tree, "build/CMakeCache.txt", false},
+		{tree, "cmake-build-debug/app", false},
+		{tree, ".cppenv/state.json", false},
+		{tree, "logs/today/run.log", false},
+		{tree, "notes.txt", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.filter.matches(tt.path); got != tt.expected {
+			t.Errorf("matches(%q) = %v, expected %v", tt.path, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package shell
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/michxymi/cppenv/internal/config"
+)
+
+// Export returns code for a shell that sets the variables in vars and
+// unsets those whose value is nil
+// Names are written unquoted, so any that is not a valid variable name is
+// an error
+func Export(kind string, vars map[string]*string) (string, error) {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		if !config.IsEnvName(name) {
+			return "", fmt.Errorf("invalid variable name %q", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		value := vars[name]
+		switch kind {
+		case Bash, Zsh, Sh:
+			if value == nil {
+				fmt.Fprintf(&b, "unset %s;\n", name)
+			} else {
+				fmt.Fprintf(&b, "export %s=%s;\n", name, singleQuote(*value))
+			}
+		case Fish:
+			switch {
+			case value == nil:
+				fmt.Fprintf(&b, "set -e %s;\n", name)
+			case strings.HasSuffix(name, "PATH"):
+				// fish keeps PATH-like variables as lists
+				fmt.Fprintf(&b, "set -gx %s (string split : -- %s);\n", name, fishQuote(*value))
+			default:
+				fmt.Fprintf(&b, "set -gx %s %s;\n", name, fishQuote(*value))
+			}
+		case PowerShell:
+			if value == nil {
+				fmt.Fprintf(&b, "Remove-Item Env:%s -ErrorAction SilentlyContinue\n", name)
+			} else {
+				fmt.Fprintf(&b, "$env:%s = %s\n", name, powerShellQuote(*value))
+			}
+		default:
+			return "", fmt.Errorf("unsupported shell %q, expected bash, zsh, fish or powershell", kind)
+		}
+	}
+	return b.String(), nil
+}
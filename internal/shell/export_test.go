@@ -0,0 +1,71 @@
+package shell
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	path, value := "/a:/b", "it's $HOME"
+	vars := map[string]*string{"PATH": &path, "FOO": &value, "OLD": nil}
+
+	expected := map[string]string{
+		Bash:       "export FOO='it'\\''s $HOME';\nunset OLD;\nexport PATH='/a:/b';\n",
+		Fish:       "set -gx FOO 'it\\'s $HOME';\nset -e OLD;\nset -gx PATH (string split : -- '/a:/b');\n",
+		PowerShell: "$env:FOO = 'it''s $HOME'\nRemove-Item Env:OLD -ErrorAction SilentlyContinue\n$env:PATH = '/a:/b'\n",
+	}
+	for kind, want := range expected {
+		got, err := Export(kind, vars)
+		if err != nil {
+			t.Fatalf("Export(%s) failed: %v", kind, err)
+		}
+		if got != want {
+			t.Errorf("Export(%s) = %q, expected %q", kind, got, want)
+		}
+	}
+
+	if _, err := Export(Cmd, vars); err == nil {
+		t.Error("expected error for cmd")
+	}
+	for _, name := range []string{"X; echo PWNED #", "1X", "A-B", ""} {
+		if _, err := Export(Bash, map[string]*string{name: &value}); err == nil {
+			t.Errorf("expected error for variable name %q", name)
+		}
+	}
+}
+
+func TestExportBash(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not found")
+	}
+
+	value := "a 'quoted' \"value\" with $dollar and \\ backslash"
+	code, err := Export(Bash, map[string]*string{"CPPENV_TEST": &value})
+	if err != nil {
+		t.Fatalf("Export() failed: %v", err)
+	}
+	out, err := exec.Command(bash, "-c", code+`printf '%s' "$CPPENV_TEST"`).Output()
+	if err != nil {
+		t.Fatalf("bash failed: %v", err)
+	}
+	if string(out) != value {
+		t.Errorf("expected %q, got %q", value, out)
+	}
+}
+
+func TestHook(t *testing.T) {
+	for _, kind := range []string{Bash, Zsh, Fish, PowerShell} {
+		code, err := Hook(kind, "/opt/cpp env/cppenv")
+		if err != nil {
+			t.Fatalf("Hook(%s) failed: %v", kind, err)
+		}
+		if !strings.Contains(code, "'/opt/cpp env/cppenv' hook-env "+kind) {
+			t.Errorf("expected %s hook to run hook-env, got:\n%s", kind, code)
+		}
+	}
+	if _, err := Hook(Cmd, "cppenv"); err == nil {
+		t.Error("expected error for cmd")
+	}
+}
@@ -0,0 +1,60 @@
+package shell
+
+import (
+	"fmt"
+	"strings"
+)
+
+// hookScripts run `cppenv hook-env` before every prompt, so that changes to
+// the project take effect without leaving its directory; hook-env prints
+// nothing if they and the directory are the same as before
+// {exe} is replaced with the quoted cppenv path
+var hookScripts = map[string]string{
+	Bash: `_cppenv_hook() {
+  local status=$?
+  eval "$({exe} hook-env bash)"
+  return $status
+}
+if [[ ";${PROMPT_COMMAND[*]:-};" != *";_cppenv_hook;"* ]]; then
+  PROMPT_COMMAND="_cppenv_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi
+`,
+	Zsh: `_cppenv_hook() {
+  eval "$({exe} hook-env zsh)"
+}
+typeset -ag precmd_functions
+if (( ! ${precmd_functions[(I)_cppenv_hook]} )); then
+  precmd_functions=(_cppenv_hook $precmd_functions)
+fi
+`,
+	Fish: `function _cppenv_hook --on-event fish_prompt
+    {exe} hook-env fish | source
+end
+`,
+	PowerShell: `if (-not $global:_cppenvPrompt) {
+    $global:_cppenvPrompt = $function:prompt
+    function global:prompt {
+        & {exe} hook-env powershell | Out-String | Invoke-Expression
+        & $global:_cppenvPrompt
+    }
+}
+`,
+}
+
+// Hook returns code that makes a shell activate the project environment
+// when it enters a directory with cppenv.toml and deactivate it when it
+// leaves; exe is the path of cppenv
+func Hook(kind, exe string) (string, error) {
+	script, ok := hookScripts[kind]
+	if !ok {
+		return "", fmt.Errorf("unsupported shell %q, expected bash, zsh, fish or powershell", kind)
+	}
+	quoted := singleQuote(exe)
+	switch kind {
+	case Fish:
+		quoted = fishQuote(exe)
+	case PowerShell:
+		quoted = powerShellQuote(exe)
+	}
+	return strings.ReplaceAll(script, "{exe}", quoted), nil
+}
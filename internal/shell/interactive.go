@@ -0,0 +1,78 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Interactive returns the command line and extra environment variables that
+// start an interactive shell whose prompt begins with "(marker) "
+// The user's own startup files are still read; binDir is put back at the
+// front of PATH after them. Startup files the shell needs are written to
+// dir, which must exist until the shell exits
+func Interactive(path, marker, binDir, dir string) ([]string, map[string]string, error) {
+	env := make(map[string]string)
+
+	switch Kind(path) {
+	case Bash:
+		rc := fmt.Sprintf("[ -f ~/.bashrc ] && . ~/.bashrc\nPATH=%s:\"$PATH\"\nPS1=%s\"$PS1\"\n",
+			singleQuote(binDir), singleQuote("("+strings.ReplaceAll(marker, `\`, `\\`)+") "))
+		rcFile := filepath.Join(dir, "bashrc")
+		if err := os.WriteFile(rcFile, []byte(rc), 0644); err != nil {
+			return nil, nil, fmt.Errorf("failed to write %s: %w", rcFile, err)
+		}
+		return []string{path, "--rcfile", rcFile, "-i"}, env, nil
+
+	case Zsh:
+		// zsh reads its startup files from $ZDOTDIR; the ones in dir read the
+		// user's own and put ZDOTDIR back
+		home := os.Getenv("ZDOTDIR")
+		if home == "" {
+			home = os.Getenv("HOME")
+		}
+		files := map[string]string{
+			".zshenv": fmt.Sprintf("[ -f %[1]s/.zshenv ] && . %[1]s/.zshenv\n", singleQuote(home)),
+			".zshrc": fmt.Sprintf("ZDOTDIR=%[1]s\n[ -f %[1]s/.zshrc ] && . %[1]s/.zshrc\nPATH=%[2]s:\"$PATH\"\nPROMPT=%[3]s\"$PROMPT\"\n",
+				singleQuote(home), singleQuote(binDir), singleQuote("("+strings.ReplaceAll(marker, "%", "%%")+") ")),
+		}
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				return nil, nil, fmt.Errorf("failed to write %s: %w", name, err)
+			}
+		}
+		env["ZDOTDIR"] = dir
+		return []string{path, "-i"}, env, nil
+
+	case Fish:
+		init := "set -gx PATH " + fishQuote(binDir) + " $PATH\n" +
+			"functions -q fish_prompt; and functions -c fish_prompt _cppenv_fish_prompt\n" +
+			"function fish_prompt; printf '%s' " + fishQuote("("+marker+") ") + "; functions -q _cppenv_fish_prompt; and _cppenv_fish_prompt; end"
+		return []string{path, "--init-command", init}, env, nil
+
+	case PowerShell:
+		init := "$env:PATH = " + powerShellQuote(binDir+string(os.PathListSeparator)) + " + $env:PATH; " +
+			"$function:_cppenv_prompt = $function:prompt; " +
+			"function global:prompt { " + powerShellQuote("("+marker+") ") + " + (& $function:_cppenv_prompt) }"
+		return []string{path, "-NoLogo", "-NoExit", "-Command", init}, env, nil
+
+	case Cmd:
+		env["PROMPT"] = "(" + marker + ") $P$G"
+		return []string{path}, env, nil
+
+	default:
+		env["PS1"] = "(" + marker + ") $ "
+		return []string{path, "-i"}, env, nil
+	}
+}
+
+// fishQuote quotes a string for fish
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+// powerShellQuote quotes a string for PowerShell
+func powerShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,72 @@
+package shell
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInteractive(t *testing.T) {
+	dir := t.TempDir()
+
+	args, _, err := Interactive("/bin/bash", "demo", "/project/bin", dir)
+	if err != nil {
+		t.Fatalf("Interactive() failed: %v", err)
+	}
+	if strings.Join(args, " ") != "/bin/bash --rcfile "+filepath.Join(dir, "bashrc")+" -i" {
+		t.Errorf("unexpected bash command line %q", args)
+	}
+	rc, err := os.ReadFile(filepath.Join(dir, "bashrc"))
+	if err != nil {
+		t.Fatalf("failed to read bashrc: %v", err)
+	}
+	if !strings.Contains(string(rc), `PATH='/project/bin':"$PATH"`) || !strings.Contains(string(rc), `PS1='(demo) '"$PS1"`) {
+		t.Errorf("expected bashrc to set PATH and the prompt, got:\n%s", rc)
+	}
+
+	_, env, err := Interactive("zsh", "100%", "/project/bin", dir)
+	if err != nil {
+		t.Fatalf("Interactive() failed: %v", err)
+	}
+	if env["ZDOTDIR"] != dir {
+		t.Errorf("expected ZDOTDIR to point at %s, got %v", dir, env)
+	}
+	if rc, _ := os.ReadFile(filepath.Join(dir, ".zshrc")); !strings.Contains(string(rc), `PROMPT='(100%%) '`) {
+		t.Errorf("expected escaped zsh prompt, got:\n%s", rc)
+	}
+
+	args, _, _ = Interactive("fish", "it's", "/project/bin", dir)
+	if !strings.Contains(args[2], `'(it\'s) '`) {
+		t.Errorf("expected quoted fish prompt, got %q", args)
+	}
+
+	_, env, _ = Interactive("dash", "demo", "/project/bin", dir)
+	if env["PS1"] != "(demo) $ " {
+		t.Errorf("expected PS1 for other shells, got %v", env)
+	}
+}
+
+func TestInteractiveBash(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not found")
+	}
+
+	dir := t.TempDir()
+	args, _, err := Interactive(bash, "demo", "/project/bin", dir)
+	if err != nil {
+		t.Fatalf("Interactive() failed: %v", err)
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = []string{"HOME=" + dir, "PATH=/usr/bin:/bin", "PS1=$ "}
+	cmd.Stdin = strings.NewReader(`echo "prompt=$PS1 path=$PATH"` + "\n")
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("bash failed: %v", err)
+	}
+	if !strings.HasPrefix(string(out), "prompt=(demo) ") || !strings.Contains(string(out), " path=/project/bin:/usr/bin:/bin\n") {
+		t.Errorf("unexpected output %q", out)
+	}
+}
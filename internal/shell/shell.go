@@ -0,0 +1,68 @@
+// Package shell starts interactive shells in the project environment and
+// writes the shell code that activates it
+package shell
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Shells cppenv knows how to activate
+const (
+	Bash       = "bash"
+	Zsh        = "zsh"
+	Fish       = "fish"
+	PowerShell = "powershell"
+	Cmd        = "cmd"
+	// Sh is any other POSIX shell
+	Sh = "sh"
+)
+
+// ActiveVar is set to the project directory in shells started by
+// 'cppenv shell', to prevent starting one inside another
+const ActiveVar = "CPPENV_ACTIVE"
+
+// ActivatedVar is set to the project directory when its environment is
+// activated in the current shell, by 'cppenv env --shell' or the hook
+const ActivatedVar = "CPPENV_ACTIVATED"
+
+// Detect returns the path of the user's shell: $SHELL, or on Windows
+// PowerShell if it is installed and cmd otherwise
+func Detect() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	if runtime.GOOS == "windows" {
+		for _, name := range []string{"pwsh.exe", "powershell.exe"} {
+			if path, err := exec.LookPath(name); err == nil {
+				return path
+			}
+		}
+		if comspec := os.Getenv("COMSPEC"); comspec != "" {
+			return comspec
+		}
+		return "cmd.exe"
+	}
+	return "/bin/sh"
+}
+
+// Kind returns which of the known shells a shell executable is
+func Kind(path string) string {
+	name := strings.ToLower(path[strings.LastIndexAny(path, `/\`)+1:])
+	name = strings.TrimSuffix(name, ".exe")
+	switch name {
+	case Bash, Zsh, Fish, Cmd:
+		return name
+	case "pwsh", PowerShell:
+		return PowerShell
+	default:
+		return Sh
+	}
+}
+
+// singleQuote quotes a string for POSIX shells
+func singleQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,20 @@
+package shell
+
+import "testing"
+
+func TestKind(t *testing.T) {
+	tests := map[string]string{
+		"/bin/bash":    Bash,
+		"/usr/bin/zsh": Zsh,
+		"fish":         Fish,
+		"pwsh":         PowerShell,
+		`C:\Windows\System32\WindowsPowerShell\v1.0\powershell.exe`: PowerShell,
+		`C:\Windows\System32\cmd.exe`:                               Cmd,
+		"/bin/dash":                                                 Sh,
+	}
+	for path, expected := range tests {
+		if got := Kind(path); got != expected {
+			t.Errorf("Kind(%q) = %q, expected %q", path, got, expected)
+		}
+	}
+}
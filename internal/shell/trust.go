@@ -0,0 +1,118 @@
+package shell
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/michxymi/cppenv/internal/python"
+)
+
+const allowedFile = "allowed.json"
+
+// allowList holds the projects the shell hook may activate: the path of
+// each cppenv.toml and the SHA-256 of the contents that were allowed
+type allowList struct {
+	Allowed map[string]string `json:"allowed"`
+}
+
+func getAllowedPath() string {
+	return filepath.Join(python.GetCppenvHome(), allowedFile)
+}
+
+// Allow lets the shell hook activate a project with the current contents of
+// its cppenv.toml
+func Allow(configPath string) error {
+	configPath, sum, err := configSum(configPath)
+	if err != nil {
+		return err
+	}
+	list, err := loadAllowed()
+	if err != nil {
+		return err
+	}
+	list.Allowed[configPath] = sum
+	return saveAllowed(list)
+}
+
+// Deny stops the shell hook from activating a project
+func Deny(configPath string) error {
+	configPath, err := filepath.Abs(configPath)
+	if err != nil {
+		return err
+	}
+	list, err := loadAllowed()
+	if err != nil {
+		return err
+	}
+	if _, ok := list.Allowed[configPath]; !ok {
+		return nil
+	}
+	delete(list.Allowed, configPath)
+	return saveAllowed(list)
+}
+
+// IsAllowed reports whether a project was allowed with the current contents
+// of its cppenv.toml
+// A cppenv.toml that changed since, e.g. after pulling, must be allowed again
+func IsAllowed(configPath string) (bool, error) {
+	configPath, sum, err := configSum(configPath)
+	if err != nil {
+		return false, err
+	}
+	list, err := loadAllowed()
+	if err != nil {
+		return false, err
+	}
+	return list.Allowed[configPath] == sum, nil
+}
+
+// configSum returns the absolute path of a cppenv.toml and the SHA-256 of
+// its contents
+func configSum(configPath string) (string, string, error) {
+	configPath, err := filepath.Abs(configPath)
+	if err != nil {
+		return "", "", err
+	}
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read %s: %w", configPath, err)
+	}
+	sum := sha256.Sum256(content)
+	return configPath, hex.EncodeToString(sum[:]), nil
+}
+
+// loadAllowed reads the allow list, returning an empty list if none exists
+func loadAllowed() (*allowList, error) {
+	list := &allowList{}
+	content, err := os.ReadFile(getAllowedPath())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", allowedFile, err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(content, list); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", allowedFile, err)
+		}
+	}
+	if list.Allowed == nil {
+		list.Allowed = make(map[string]string)
+	}
+	return list, nil
+}
+
+func saveAllowed(list *allowList) error {
+	if err := os.MkdirAll(python.GetCppenvHome(), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", python.GetCppenvHome(), err)
+	}
+	content, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if err := os.WriteFile(getAllowedPath(), content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", allowedFile, err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAllow(t *testing.T) {
+	t.Setenv("CPPENV_HOME", t.TempDir())
+	configPath := filepath.Join(t.TempDir(), "cppenv.toml")
+	if err := os.WriteFile(configPath, []byte("[project]\nname = \"demo\"\n"), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	if allowed, err := IsAllowed(configPath); err != nil || allowed {
+		t.Errorf("expected a new project not to be allowed, got %v, %v", allowed, err)
+	}
+	if err := Allow(configPath); err != nil {
+		t.Fatalf("Allow() failed: %v", err)
+	}
+	if allowed, err := IsAllowed(configPath); err != nil || !allowed {
+		t.Errorf("expected the project to be allowed, got %v, %v", allowed, err)
+	}
+
+	// Changing cppenv.toml needs another allow
+	if err := os.WriteFile(configPath, []byte("[project]\nname = \"changed\"\n"), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+	if allowed, _ := IsAllowed(configPath); allowed {
+		t.Error("expected a changed project not to be allowed")
+	}
+
+	if err := Allow(configPath); err != nil {
+		t.Fatalf("Allow() failed: %v", err)
+	}
+	if err := Deny(configPath); err != nil {
+		t.Fatalf("Deny() failed: %v", err)
+	}
+	if allowed, _ := IsAllowed(configPath); allowed {
+		t.Error("expected a denied project not to be allowed")
+	}
+}
@@ -0,0 +1,80 @@
+package shims
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/michxymi/cppenv/internal/config"
+	"github.com/michxymi/cppenv/internal/python"
+)
+
+const projectsFile = "projects.json"
+
+// projectList is the list of projects installed on this machine
+type projectList struct {
+	Projects []string `json:"projects"`
+}
+
+func getProjectsPath() string {
+	return filepath.Join(python.GetCppenvHome(), projectsFile)
+}
+
+// RegisterProject records a project directory so that shims are created
+// for its tools
+func RegisterProject(root string) error {
+	list, err := loadProjects()
+	if err != nil {
+		return err
+	}
+	if slices.Contains(list.Projects, root) {
+		return nil
+	}
+	list.Projects = append(list.Projects, root)
+	slices.Sort(list.Projects)
+
+	if err := os.MkdirAll(python.GetCppenvHome(), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", python.GetCppenvHome(), err)
+	}
+	content, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if err := os.WriteFile(getProjectsPath(), content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", projectsFile, err)
+	}
+	return nil
+}
+
+// Projects returns the registered projects that still have a cppenv.toml
+func Projects() ([]string, error) {
+	list, err := loadProjects()
+	if err != nil {
+		return nil, err
+	}
+	var projects []string
+	for _, root := range list.Projects {
+		if _, err := os.Stat(filepath.Join(root, config.ConfigFile)); err == nil {
+			projects = append(projects, root)
+		}
+	}
+	return projects, nil
+}
+
+// loadProjects reads the project list, returning an empty list if none exists
+func loadProjects() (*projectList, error) {
+	list := &projectList{}
+	content, err := os.ReadFile(getProjectsPath())
+	if os.IsNotExist(err) {
+		return list, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", projectsFile, err)
+	}
+	if err := json.Unmarshal(content, list); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", projectsFile, err)
+	}
+	return list, nil
+}
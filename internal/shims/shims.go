@@ -0,0 +1,272 @@
+// Package shims creates executables in ~/.cppenv/shims that run the tools
+// of the nearest project, so they can be used without 'cppenv run'
+package shims
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/michxymi/cppenv/internal/config"
+	"github.com/michxymi/cppenv/internal/environment"
+	"github.com/michxymi/cppenv/internal/python"
+)
+
+// Dir returns the directory holding the shims
+func Dir() string {
+	return filepath.Join(python.GetCppenvHome(), "shims")
+}
+
+// Installed reports whether shims have been installed
+func Installed() bool {
+	_, err := os.Stat(Dir())
+	return err == nil
+}
+
+// venvEntryPoint matches the interpreter and pip in a venv's bin directory,
+// e.g. python3.12 or pip3
+var venvEntryPoint = regexp.MustCompile(`^(python|pythonw|pip|pydoc)[0-9.]*$`)
+
+// Install creates a shim for every binary 'cppenv run' puts on PATH in the
+// registered projects and removes shims for binaries no project has anymore
+// Shims are links to the cppenv executable; it returns their names, sorted
+// Projects that cannot be loaded are skipped and returned as skipped errors
+func Install() (names []string, skipped []error, err error) {
+	exe, err := executable()
+	if err != nil {
+		return nil, nil, err
+	}
+	projects, err := Projects()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wanted := make(map[string]bool)
+	for _, root := range projects {
+		binaries, err := projectBinaries(root)
+		if err != nil {
+			skipped = append(skipped, err)
+			continue
+		}
+		for _, name := range binaries {
+			if !noShim(name) {
+				wanted[name] = true
+			}
+		}
+	}
+
+	dir := Dir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	for _, entry := range entries {
+		if !wanted[shimName(entry.Name())] {
+			if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+				return nil, nil, fmt.Errorf("failed to remove shim %s: %w", entry.Name(), err)
+			}
+		}
+	}
+
+	names = make([]string, 0, len(wanted))
+	for name := range wanted {
+		if err := createShim(exe, filepath.Join(dir, name+exeSuffix())); err != nil {
+			return nil, nil, err
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, skipped, nil
+}
+
+// noShim reports whether a binary gets no shim: cppenv itself, the venv's
+// activation scripts, and its Python and pip with isolation = "venv", which
+// would take the place of the user's own in every project
+func noShim(name string) bool {
+	name = strings.ToLower(name)
+	return name == "cppenv" || strings.HasPrefix(name, "activate") || name == "deactivate" ||
+		venvEntryPoint.MatchString(name)
+}
+
+// projectBinaries returns the binaries 'cppenv run' puts on PATH in a project
+func projectBinaries(root string) ([]string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	defer os.Chdir(cwd)
+	if err := os.Chdir(root); err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.Load(config.ConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", filepath.Join(root, config.ConfigFile), err)
+	}
+	if !environment.Exists() {
+		return nil, nil
+	}
+	return environment.GetRunBinaries(cfg), nil
+}
+
+// createShim links path to the cppenv executable, copying it if the file
+// system supports neither symbolic nor hard links
+func createShim(exe, path string) error {
+	if sameFile(exe, path) {
+		return nil
+	}
+	os.Remove(path)
+	if os.Symlink(exe, path) == nil || os.Link(exe, path) == nil {
+		return nil
+	}
+
+	src, err := os.Open(exe)
+	if err != nil {
+		return fmt.Errorf("failed to create shim %s: %w", path, err)
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create shim %s: %w", path, err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return fmt.Errorf("failed to create shim %s: %w", path, err)
+	}
+	return dst.Close()
+}
+
+// IsShim reports whether cppenv was started through a shim, given the name
+// it was started as, and returns the name of the tool the shim stands for
+func IsShim(arg0 string) (string, bool) {
+	name := shimName(filepath.Base(arg0))
+	if name == "" || name == "cppenv" {
+		return "", false
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return "", false
+	}
+	// Windows reports the path of the shim itself, other systems resolve the link
+	if filepath.Dir(exe) == Dir() || sameFile(exe, filepath.Join(Dir(), name+exeSuffix())) {
+		return name, true
+	}
+	return "", false
+}
+
+// Run runs the tool a shim stands for and returns its exit code
+// Inside a project, that is the project's binary of that name, run in the
+// same environment as 'cppenv run' would; elsewhere it is the next program
+// of that name on PATH after the shims
+func Run(name string, args []string) int {
+	if path, env, ok := projectTool(name); ok {
+		return (&environment.Command{Args: append([]string{path}, args...), Env: env}).Run()
+	}
+
+	path := lookPathAfterShims(name)
+	if path == "" {
+		fmt.Fprintf(os.Stderr, "cppenv: %s: command not found\n", name)
+		return 127
+	}
+	return (&environment.Command{Args: append([]string{path}, args...), Env: os.Environ()}).Run()
+}
+
+// projectTool finds a binary in the project containing the current directory
+// Problems with the project are reported as warnings, falling back to PATH
+func projectTool(name string) (string, []string, bool) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", nil, false
+	}
+	configPath, err := config.FindProject(cwd)
+	if err != nil {
+		return "", nil, false
+	}
+	root := filepath.Dir(configPath)
+
+	// The environment is found relative to the working directory
+	defer os.Chdir(cwd)
+	if err := os.Chdir(root); err != nil {
+		return "", nil, false
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cppenv: failed to load %s: %v\n", configPath, err)
+		return "", nil, false
+	}
+	if !environment.Exists() {
+		fmt.Fprintf(os.Stderr, "cppenv: environment for %s not found, run 'cppenv install'; using %s from PATH\n", cfg.Project.Name, name)
+		return "", nil, false
+	}
+	if err := environment.SetProjectEnv(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "cppenv: %v\n", err)
+		return "", nil, false
+	}
+	return environment.LookupRunBinary(cfg, name)
+}
+
+// lookPathAfterShims searches PATH for a program, skipping the shims directory
+func lookPathAfterShims(name string) string {
+	dir := Dir()
+	var paths []string
+	for _, p := range filepath.SplitList(os.Getenv("PATH")) {
+		if p != "" && !sameFile(p, dir) {
+			paths = append(paths, p)
+		}
+	}
+	env := []string{"PATH=" + strings.Join(paths, string(os.PathListSeparator))}
+	if path := environment.LookPath(name, env); path != name {
+		return path
+	}
+	return ""
+}
+
+// executable returns the path of the cppenv executable with links resolved
+func executable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the cppenv executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return exe, nil
+}
+
+// shimName returns the tool name of a shim file
+func shimName(file string) string {
+	if runtime.GOOS == "windows" {
+		if ext := filepath.Ext(file); strings.EqualFold(ext, ".exe") {
+			return strings.TrimSuffix(file, ext)
+		}
+	}
+	return file
+}
+
+func exeSuffix() string {
+	if runtime.GOOS == "windows" {
+		return ".exe"
+	}
+	return ""
+}
+
+// sameFile reports whether two paths resolve to the same file
+func sameFile(a, b string) bool {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(infoA, infoB)
+}
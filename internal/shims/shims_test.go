@@ -0,0 +1,137 @@
+package shims
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+// setupProject creates a home directory and an installed project with a
+// fake cmake that writes its arguments and FOO to out.txt
+func setupProject(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	root := t.TempDir()
+	bin := filepath.Join(root, ".cppenv", "venv", "bin")
+	if err := os.MkdirAll(bin, 0755); err != nil {
+		t.Fatalf("failed to create venv: %v", err)
+	}
+	script := "#!/bin/sh\necho \"$@ $FOO\" > out.txt\n"
+	if err := os.WriteFile(filepath.Join(bin, "cmake"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake cmake: %v", err)
+	}
+	for _, name := range []string{"activate", "python", "python3.12", "pip3"} {
+		if err := os.WriteFile(filepath.Join(bin, name), []byte(""), 0755); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	config := "[project]\nname = \"demo\"\n[run]\nisolation = \"venv\"\n[env]\nFOO = \"bar\"\n"
+	if err := os.WriteFile(filepath.Join(root, "cppenv.toml"), []byte(config), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return root
+}
+
+func TestInstall(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake tools are shell scripts")
+	}
+	root := setupProject(t)
+
+	if err := RegisterProject(root); err != nil {
+		t.Fatalf("RegisterProject() failed: %v", err)
+	}
+	if err := RegisterProject(root); err != nil {
+		t.Fatalf("RegisterProject() failed: %v", err)
+	}
+	projects, err := Projects()
+	if err != nil || !slices.Equal(projects, []string{root}) {
+		t.Fatalf("expected the project to be registered once, got %v, %v", projects, err)
+	}
+
+	stale := filepath.Join(Dir(), "old-tool")
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		t.Fatalf("failed to create shims directory: %v", err)
+	}
+	if err := os.WriteFile(stale, nil, 0755); err != nil {
+		t.Fatalf("failed to write stale shim: %v", err)
+	}
+
+	// A broken project is skipped instead of failing the others
+	broken := t.TempDir()
+	if err := os.WriteFile(filepath.Join(broken, "cppenv.toml"), []byte("[project\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := RegisterProject(broken); err != nil {
+		t.Fatalf("RegisterProject() failed: %v", err)
+	}
+
+	names, skipped, err := Install()
+	if err != nil {
+		t.Fatalf("Install() failed: %v", err)
+	}
+	if !slices.Equal(names, []string{"cmake"}) {
+		t.Errorf("expected only a cmake shim, got %v", names)
+	}
+	if len(skipped) != 1 {
+		t.Errorf("expected the broken project to be skipped, got %v", skipped)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("expected the stale shim to be removed")
+	}
+
+	if name, ok := IsShim(filepath.Join(Dir(), "cmake")); !ok || name != "cmake" {
+		t.Errorf("expected the cmake shim to be recognized, got %q, %v", name, ok)
+	}
+	if _, ok := IsShim(os.Args[0]); ok {
+		t.Error("expected the test binary not to be a shim")
+	}
+}
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake tools are shell scripts")
+	}
+	root := setupProject(t)
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	defer os.Chdir(cwd)
+	sub := filepath.Join(root, "src")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.Chdir(sub); err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+
+	if code := Run("cmake", []string{"--version"}); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	out, err := os.ReadFile(filepath.Join(sub, "out.txt"))
+	if err != nil {
+		t.Fatalf("expected the project's cmake to run in the current directory: %v", err)
+	}
+	if string(out) != "--version bar\n" {
+		t.Errorf("expected arguments and [env] to be passed, got %q", out)
+	}
+
+	outside := t.TempDir()
+	if err := os.Chdir(outside); err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+	if code := Run("cppenv-missing-tool", nil); code != 127 {
+		t.Errorf("expected exit code 127 outside projects, got %d", code)
+	}
+	t.Setenv("PATH", Dir()+string(os.PathListSeparator)+filepath.Join(root, ".cppenv", "venv", "bin"))
+	if path := lookPathAfterShims("cmake"); path != filepath.Join(root, ".cppenv", "venv", "bin", "cmake") {
+		t.Errorf("expected cmake from PATH, got %q", path)
+	}
+}
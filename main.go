@@ -5,9 +5,17 @@ import (
 	"os"
 
 	"github.com/michxymi/cppenv/internal/cli"
+	"github.com/michxymi/cppenv/internal/environment"
+	"github.com/michxymi/cppenv/internal/shims"
 )
 
 func main() {
+	// cppenv --offline re-executes itself to set up the network namespace
+	environment.RunOfflineHelper(os.Args)
+	if name, ok := shims.IsShim(os.Args[0]); ok {
+		os.Exit(shims.Run(name, os.Args[1:]))
+	}
+
 	if err := cli.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)